@@ -0,0 +1,234 @@
+package godmenu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+)
+
+// Backend describes a menu program that godmenu can drive. The
+// Options and selections are validated by godmenu before the backend
+// is called; backends are only responsible for presenting the
+// selections and reporting what the user chose.
+type Backend interface {
+	// Name identifies the backend in errors.
+	Name() string
+	// Validate reports if the backend can run with the given
+	// options, for instance that the launcher is installed.
+	Validate(*Options) error
+	// Render writes the (already ordered) selections in the
+	// format that the launcher reads. The selections may be
+	// produced while the launcher runs, and so should be written
+	// as they arrive. The writer is buffered, so small writes are
+	// cheap.
+	Render(w io.Writer, selections iter.Seq[string]) error
+	// Run invokes the launcher, providing the rendered selections
+	// as input, and returns its output.
+	Run(ctx context.Context, opts *Options, input io.Reader) ([]byte, error)
+	// Parse interprets the output and error returned by Run,
+	// returning the selection output that godmenu will process.
+	Parse(output []byte, err error) (Response, error)
+}
+
+// Response is the output of a launcher, as interpreted by its
+// backend.
+type Response struct {
+	// Output holds the selection(s), one per line.
+	Output []byte
+	// Query is the text that the user entered, for launchers
+	// that report it separately from the selection.
+	Query string
+	// Key is the key that the user pressed to accept the
+	// selection, for launchers that report it.
+	Key string
+	// Indexes, for launchers that report them, hold the position
+	// in the rendered selections of each line of the output, or
+	// -1 for text that the user entered.
+	Indexes []int
+}
+
+// DMenu returns the default backend, which runs dmenu.
+func DMenu() Backend { return dmenuBackend }
+
+var dmenuBackend = &launcher{name: "dmenu", program: DefaultDMenuPath, args: dmenuArgs, multi: true}
+
+// launcher implements Backend for programs which follow dmenu's
+// conventions: newline separated selections on standard input, and
+// the selection on standard output.
+type launcher struct {
+	name    string
+	program string
+	args    func(*Options) []string
+	// parse, when set, interprets the launcher's exit status;
+	// otherwise output and errors are passed through.
+	parse func([]byte, error) (Response, error)
+	// validate, when set, checks that the flags can be expressed
+	// using the launcher's options.
+	validate func(*Flags) error
+	// multi is true for launchers which can return more than one
+	// selection.
+	multi bool
+	// indexes is true for launchers which report the position of
+	// the selection (as Response.Indexes), and so can present
+	// duplicate selections without disambiguating them.
+	indexes bool
+	// terminal is true for launchers which draw in the terminal
+	// on standard error, which is passed through rather than
+	// captured.
+	terminal bool
+}
+
+// indexedBackend is implemented by backends which report the
+// position of each selection.
+type indexedBackend interface{ indexed() bool }
+
+func (l *launcher) Name() string             { return l.name }
+func (l *launcher) indexed() bool            { return l.indexes }
+func (l *launcher) path(flags *Flags) string { return loadDefault(flags.Path, l.program) }
+
+func (l *launcher) Parse(out []byte, err error) (Response, error) {
+	if l.parse != nil {
+		return l.parse(out, err)
+	}
+
+	// dmenu, and the launchers which imitate it, exit with 1
+	// when the user dismisses the menu. Launchers may also write
+	// warnings (e.g. about font fallback) to standard error, so
+	// output there does not make a dismissal into a failure.
+	code, ok := exitCode(err)
+	switch {
+	case err == nil:
+		return Response{Output: out}, nil
+	case ok && code == 1 && len(bytes.TrimSpace(out)) == 0:
+		return Response{}, canceled(l.name, err)
+	default:
+		return Response{}, launchError(l.name, err)
+	}
+}
+
+func (l *launcher) Validate(opts *Options) error {
+	var errs []error
+	path := l.path(opts.Flags)
+	if _, err := opts.runner().LookPath(path); err != nil {
+		errs = append(errs, fmt.Errorf("could not find path %q to %s: %w", path, l.name, err))
+	}
+	if opts.Multi && !l.multi {
+		errs = append(errs, fmt.Errorf("%s does not support multiple selections", l.name))
+	}
+	if l.validate != nil {
+		if err := l.validate(opts.Flags); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", l.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Render writes one selection per line, without a final newline.
+func (*launcher) Render(w io.Writer, selections iter.Seq[string]) error {
+	sep := ""
+	for sel := range selections {
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, sel); err != nil {
+			return err
+		}
+		sep = "\n"
+	}
+	return nil
+}
+
+// Run runs the launcher with the options' Runner, which captures
+// standard output and standard error separately, so that warnings
+// never become part of the selection. Standard error is passed to the
+// options' StderrHook, and reported in DmenuErrors, except for
+// terminal launchers, which draw on it, and so write it directly to
+// the process's standard error.
+func (l *launcher) Run(ctx context.Context, opts *Options, input io.Reader) ([]byte, error) {
+	cmd := Command{
+		Program: l.path(opts.Flags),
+		Args:    l.args(opts),
+		Stdin:   input,
+		Env:     opts.Env,
+		Dir:     opts.Dir,
+	}
+	if l.terminal {
+		cmd.Stderr = os.Stderr
+	}
+
+	stdout, stderr, err := opts.runner().Run(ctx, cmd)
+	if len(stderr) > 0 && opts.StderrHook != nil {
+		opts.StderrHook(l.name, string(stderr))
+	}
+	if err != nil {
+		return stdout, newDmenuError(l.name, err, stderr)
+	}
+
+	return stdout, nil
+}
+
+// exitCode reports the exit status of a launcher that ran and exited
+// unsuccessfully, from an *exec.ExitError or any other error which
+// reports an exit code.
+func exitCode(err error) (int, bool) {
+	var exerr interface{ ExitCode() int }
+	if !errors.As(err, &exerr) {
+		return 0, false
+	}
+	return exerr.ExitCode(), true
+}
+
+func dmenuArgs(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 20)
+
+	if !conf.CaseSensitive {
+		args = append(args, "-i")
+	}
+
+	if conf.Bottom {
+		args = append(args, "-b")
+	}
+
+	if conf.Lines > 0 {
+		args = append(args, "-l", fmt.Sprint(conf.Lines))
+	}
+
+	if conf.Font != "" {
+		args = append(args, "-fn", conf.Font)
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "-p", conf.Prompt)
+	}
+
+	if conf.BackgroundColor != "" {
+		args = append(args, "-nb", conf.BackgroundColor)
+	}
+
+	if conf.SelectedBgColor != "" {
+		args = append(args, "-sb", conf.SelectedBgColor)
+	}
+
+	if conf.TextColor != "" {
+		args = append(args, "-nf", conf.TextColor)
+	}
+
+	if conf.SelectedTextColor != "" {
+		args = append(args, "-sf", conf.SelectedTextColor)
+	}
+
+	if conf.Monitor != nil {
+		args = append(args, "-m", fmt.Sprint(*conf.Monitor))
+	}
+
+	if conf.WindowID != nil {
+		args = append(args, "-w", fmt.Sprint(*conf.WindowID))
+	}
+
+	return args
+}
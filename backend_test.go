@@ -0,0 +1,174 @@
+package godmenu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type mockBackend struct {
+	input  string
+	output string
+	err    error
+	opts   *Options
+}
+
+func (*mockBackend) Name() string            { return "mock" }
+func (*mockBackend) Validate(*Options) error { return nil }
+func (*mockBackend) Parse(out []byte, err error) (Response, error) {
+	return Response{Output: out}, err
+}
+
+func (*mockBackend) Render(w io.Writer, selections iter.Seq[string]) error {
+	return dmenuBackend.Render(w, selections)
+}
+
+func (m *mockBackend) Run(_ context.Context, opts *Options, input io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	m.input = string(data)
+	m.opts = opts
+	return []byte(m.output), m.err
+}
+
+func TestBackend(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		if ResolveOptions().backend() != DMenu() {
+			t.Error("expected dmenu to be the default backend")
+		}
+		if DMenu().Name() != "dmenu" {
+			t.Error(DMenu().Name())
+		}
+	})
+	t.Run("Custom", func(t *testing.T) {
+		mb := &mockBackend{output: "two\n"}
+		out, err := Run(t.Context(), WithBackend(mb), Items("one", "two", "three"), Sorted())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "two" {
+			t.Error(out)
+		}
+		if mb.input != "one\nthree\ntwo" {
+			t.Errorf("%q", mb.input)
+		}
+		if mb.opts == nil || mb.opts.Flags == nil {
+			t.Error("backend should receive resolved options")
+		}
+	})
+	t.Run("ProcessesOutput", func(t *testing.T) {
+		mb := &mockBackend{output: "four"}
+		out, err := Run(t.Context(), WithBackend(mb), Items("one", "two"), RequireMatch())
+		if !errors.Is(err, ErrSelectionUnknown) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		mb := &mockBackend{err: context.Canceled}
+		out, err := Run(t.Context(), WithBackend(mb), Items("one", "two"))
+		if !errors.Is(err, context.Canceled) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("ConfirmSubstitution", func(t *testing.T) {
+		mb := &mockBackend{output: "accept"}
+		out, err := Run(t.Context(),
+			WithBackend(mb),
+			Items("one", "two"),
+			func(o *Options) { o.Transform = func(string) string { return "ONE" } },
+			ConfirmSubstituion(),
+		)
+		if err != nil || out != "ONE" {
+			t.Error(out, err)
+		}
+		if mb.input != "ONE\naccept\nreject" {
+			t.Errorf("unexpected confirmation menu %q", mb.input)
+		}
+	})
+	t.Run("DMenuArguments", func(t *testing.T) {
+		opts := ResolveOptions(MenuPrompt("=>"), MenuLines(4), MenuBottom(), CaseSensitive())
+		args := dmenuArgs(opts)
+		for _, expected := range [][]string{{"-p", "=>"}, {"-l", "4"}, {"-b"}, {"-nb", DefaultBackgroundColor}} {
+			if !slices.ContainsFunc(args, func(a string) bool { return a == expected[0] }) {
+				t.Errorf("missing %v in %v", expected, args)
+				continue
+			}
+			idx := slices.Index(args, expected[0])
+			if !slices.Equal(args[idx:idx+len(expected)], expected) {
+				t.Errorf("expected %v in %v", expected, args)
+			}
+		}
+		if slices.Contains(args, "-i") {
+			t.Error("case sensitive menus should not pass -i", args)
+		}
+	})
+	t.Run("Path", func(t *testing.T) {
+		opts := ResolveOptions()
+		if path := dmenuBackend.path(opts.Flags); path != DefaultDMenuPath {
+			t.Error(path)
+		}
+		opts = ResolveOptions(DMenuPath("/opt/bin/dmenu"))
+		if path := dmenuBackend.path(opts.Flags); path != "/opt/bin/dmenu" {
+			t.Error(path)
+		}
+		err := dmenuBackend.Validate(ResolveOptions(DMenuPath("/does/not/exist/dmenu")))
+		if err == nil {
+			t.Error("expected missing dmenu to fail validation")
+		}
+	})
+}
+
+// standIn writes an executable to a temporary directory which records
+// its arguments and standard input, writes output to standard output,
+// and exits with the given code. The returned function reports what
+// the most recent invocation recorded.
+func standIn(t *testing.T, output string, code int) (string, func() ([]string, string)) {
+	t.Helper()
+	return standInWithStderr(t, output, "", code)
+}
+
+// standInWithStderr writes a stand-in launcher, as standIn, which
+// also writes stderr to standard error.
+func standInWithStderr(t *testing.T, output, stderr string, code int) (string, func() ([]string, string)) {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("stand-in launchers require a posix shell")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "launcher")
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %q\ncat > %q\ncat %q >&2\ncat %q\nexit %d\n",
+		filepath.Join(dir, "args"), filepath.Join(dir, "stdin"), filepath.Join(dir, "stderr"), filepath.Join(dir, "stdout"), code)
+
+	if err := os.WriteFile(filepath.Join(dir, "stdout"), []byte(output), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stderr"), []byte(stderr), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, func() ([]string, string) {
+		args, err := os.ReadFile(filepath.Join(dir, "args"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdin, err := os.ReadFile(filepath.Join(dir, "stdin"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSuffix(string(args), "\n"), "\n"), string(stdin)
+	}
+}
@@ -0,0 +1,90 @@
+package godmenu
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// discardRunner reads, and discards, the rendered selections, and
+// selects the last of them.
+type discardRunner struct{ selection string }
+
+func (discardRunner) LookPath(program string) (string, error) { return program, nil }
+
+func (r discardRunner) Run(_ context.Context, cmd Command) ([]byte, []byte, error) {
+	if _, err := io.Copy(io.Discard, cmd.Stdin); err != nil {
+		return nil, nil, err
+	}
+	return []byte(r.selection + "\n"), nil, nil
+}
+
+func benchmarkSizes(b *testing.B, fn func(b *testing.B, selections []string)) {
+	for _, size := range []int{1_000, 100_000, 1_000_000} {
+		selections := make([]string, size)
+		for idx := range selections {
+			selections[idx] = "/home/user/src/project/" + strconv.Itoa(size-idx) + ".go"
+		}
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			b.ReportAllocs()
+			fn(b, selections)
+		})
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	b.Run("Init", func(b *testing.B) {
+		benchmarkSizes(b, func(b *testing.B, selections []string) {
+			for b.Loop() {
+				newset(selections)
+			}
+		})
+	})
+	b.Run("Items", func(b *testing.B) {
+		benchmarkSizes(b, func(b *testing.B, selections []string) {
+			items := make([]Item, len(selections))
+			for idx, sel := range selections {
+				items[idx] = MakeItem(sel, strconv.Itoa(idx))
+			}
+			for b.Loop() {
+				newItemSet(items, 0)
+			}
+		})
+	})
+	b.Run("Render", func(b *testing.B) {
+		for name, sorted := range map[string]bool{"Unsorted": false, "Sorted": true} {
+			b.Run(name, func(b *testing.B) {
+				benchmarkSizes(b, func(b *testing.B, selections []string) {
+					st := newset(selections)
+					for b.Loop() {
+						if err := dmenuBackend.Render(io.Discard, st.present(st.order(sorted), false)); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			})
+		}
+	})
+}
+
+func BenchmarkRun(b *testing.B) {
+	for name, args := range map[string][]Arg{
+		"Default":      nil,
+		"RequireMatch": {RequireMatch()},
+		"Sorted":       {Sorted()},
+		"Rofi":         {WithBackend(Rofi())},
+	} {
+		b.Run(name, func(b *testing.B) {
+			benchmarkSizes(b, func(b *testing.B, selections []string) {
+				opts := ResolveOptions(append(args, WithRunner(discardRunner{selection: selections[0]}), SetSelections(selections))...)
+				for b.Loop() {
+					if _, err := Do(b.Context(), *opts); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -1,15 +1,24 @@
 package godmenu
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os/exec"
+	"iter"
+	"slices"
 	"strconv"
 	"strings"
 )
 
 const (
 	DefaultDMenuPath               = "dmenu"
+	DefaultRofiPath                = "rofi"
+	DefaultBemenuPath              = "bemenu"
+	DefaultWmenuPath               = "wmenu"
+	DefaultFuzzelPath              = "fuzzel"
+	DefaultTofiPath                = "tofi"
+	DefaultWofiPath                = "wofi"
+	DefaultFzfPath                 = "fzf"
 	DefaultFont                    = "Source Code Pro-13"
 	DefaultBackgroundColor         = "#000000"
 	DefaultTextColor               = "#ffffff"
@@ -21,10 +30,31 @@ var defaultDmenuConfig Flags
 
 func init() { defaultDmenuConfig.fillDefault() }
 
-// Options defines an DMenu operation.
+// Options defines an DMenu operation. Running a menu never modifies
+// its Options, or the slices and Flags that they refer to, and Args
+// copy, rather than extend or modify, what they share with other
+// Options. The same Options may be used for several menus at once,
+// provided that its Backend, Runner, Sources and Stream are safe to
+// share: a Stream, in particular, is consumed by each menu.
 type Options struct {
 	// Selections are the options presented to dmenu.
 	Selections []string
+	// Items are presented after the Selections, and return their
+	// value, rather than their label, when selected.
+	Items []Item
+	// Sources produce items when the menu runs, which are
+	// presented after the Items.
+	Sources []Source
+	// Stream, when set, produces selections which are presented
+	// after the Selections, Items and Sources, as they arrive, while the
+	// menu runs. Because the menu is already open, blank and
+	// duplicate streamed selections are omitted rather than
+	// rejected (duplicates are kept, with a counter, when
+	// AllowDuplicates is set), and streamed selections cannot be
+	// sorted. An error from the stream ends the menu. Launchers
+	// which read all of their input before they open (e.g. dmenu)
+	// open when the stream ends.
+	Stream iter.Seq2[string, error]
 	// Flags, which may be nil--resulting in the defaults defined
 	// in the godmenu package--describe the commandline options
 	// passed to DMenu.
@@ -32,7 +62,20 @@ type Options struct {
 	// Sorted, when true, causes godmenu to sort the Selections
 	// before they're passed to DMenu.
 	Sorted bool
-	// AllowDuplicates
+	// History, when set, records the selections that the menu
+	// returns, and presents the selections that are chosen most
+	// often and most recently first (followed by the others,
+	// sorted when Sorted is set).
+	History *History
+	// Policy describes how the selections are normalized, and
+	// which blank and duplicate selections are omitted.
+	Policy SelectionPolicy
+	// AllowDuplicates keeps selections which appear more than
+	// once, rather than rejecting them. Backends which report
+	// the position of the selection (rofi) show duplicates as
+	// they are; otherwise the second and later copies are shown
+	// with a counter (e.g. "a (2)"). Either way the result
+	// reports the index of the copy that the user chose.
 	AllowDuplicates bool
 	// RequireMatch, when true requires that output of the dmenu
 	// operation is in the selections operation.
@@ -43,6 +86,25 @@ type Options struct {
 	// ConfirmSubstitution instructs the application to display a second menu to confirm or
 	// modify the _actual_ selection.
 	ConfirmSubstitution bool
+	// Multi allows the user to choose more than one selection,
+	// for backends which support it. Use DoMulti or RunMulti to
+	// retrieve the selections.
+	Multi bool
+	// Backend, which may be nil--resulting in the dmenu
+	// backend--determines which menu program godmenu runs.
+	Backend Backend
+	// StderrHook, when set, receives the name of the backend and
+	// anything the launcher writes to standard error, such as
+	// warnings, whether or not it succeeds.
+	StderrHook func(backend, stderr string)
+	// Runner, which may be nil--resulting in ExecRunner--finds
+	// and runs the programs of launcher backends.
+	Runner Runner
+	// Env holds environment variables ("key=value") added to the
+	// launcher's environment.
+	Env []string
+	// Dir, when set, is the launcher's working directory.
+	Dir string
 }
 
 // Arg is a type for functional arguments.
@@ -52,11 +114,69 @@ func newop() *Options                     { return &Options{} }
 func (op Options) ref() Options           { return op }
 func (op *Options) with(opt Arg) *Options { opt(op); return op }
 
+// clone returns a copy of the options, with their own Flags, which
+// are the defaults when the options have none.
+func (op *Options) clone() Options {
+	out := *op
+	out.Flags = op.Flags.clone()
+	return *out.flags()
+}
+
+func (op *Options) runner() Runner {
+	if op.Runner == nil {
+		return ExecRunner{}
+	}
+	return op.Runner
+}
+
+// loadSources adds the items of the sources to the Items, without
+// modifying the caller's Items.
+func (op *Options) loadSources(ctx context.Context) error {
+	if len(op.Sources) == 0 {
+		return nil
+	}
+
+	items := slices.Clone(op.Items)
+	for _, src := range op.Sources {
+		srcItems, err := loadSource(ctx, src)
+		if err != nil {
+			return err
+		}
+		items = append(items, srcItems...)
+	}
+
+	op.Items = items
+	op.Sources = nil
+	return nil
+}
+
+// source returns the name of the source of the selection at the
+// position (as in Result.Index), once the sources are loaded.
+func (op *Options) source(idx int) string {
+	idx -= len(op.Selections)
+	if idx < 0 || idx >= len(op.Items) {
+		return ""
+	}
+	return op.Items[idx].Source
+}
+
 func (op *Options) selections() *set {
-	return newset(op.Selections).
+	var selections *set
+	if len(op.Items) == 0 {
+		selections = (&set{}).withPolicy(op.Policy).init(op.Selections)
+	} else {
+		items := make([]Item, 0, len(op.Selections)+len(op.Items))
+		for _, sel := range op.Selections {
+			items = append(items, Item{Label: sel})
+		}
+		selections = newItemSet(append(items, op.Items...), op.Policy)
+	}
+
+	return selections.
 		withRequireMatch(op.RequireMatch).
 		withTransform(op.Transform).
-		withAllowDuplicates(op.AllowDuplicates)
+		withAllowDuplicates(op.AllowDuplicates).
+		withStream(op.Stream != nil)
 }
 
 func (op *Options) flags() *Options {
@@ -68,6 +188,13 @@ func (op *Options) flags() *Options {
 	return op
 }
 
+func (op *Options) backend() Backend {
+	if op.Backend == nil {
+		return DMenu()
+	}
+	return op.Backend
+}
+
 func (op *Options) apply(opts []Arg) *Options {
 	op = op.flags()
 	for _, opt := range opts {
@@ -78,19 +205,32 @@ func (op *Options) apply(opts []Arg) *Options {
 }
 
 func (op *Options) extendSelections(s []string) *Options {
-	op.Selections = append(op.Selections, s...)
+	op.Selections = extend(op.Selections, s...)
 	return op
 }
 
+// extend appends the values to a copy of the slice, which may share
+// its backing array with other Options.
+func extend[T any](slice []T, values ...T) []T {
+	return append(slice[:len(slice):len(slice)], values...)
+}
+
 func (op *Options) validate() (*set, error) {
+	// the flags may be shared with the caller (and other
+	// menus), and so are copied before the defaults are filled.
+	op.Flags = op.Flags.clone()
 	op.flags()
 	op.Flags.fillDefault()
+	op.detect()
 
 	selections := op.selections()
 
 	errs := []error{
 		op.Flags.validate(),
+		op.backend().Validate(op),
 		selections.validate(),
+		op.Policy.validate(op.AllowDuplicates),
+		op.History.validate(),
 	}
 	if op.RequireMatch && op.Transform != nil {
 		errs = append(errs, errors.New("the combination of the requireMatch option and transform function is ambiguous."))
@@ -100,6 +240,18 @@ func (op *Options) validate() (*set, error) {
 		errs = append(errs, errors.New("the confirmSubstitution option without the transform function is ambiguous."))
 	}
 
+	if op.Stream != nil && op.Sorted {
+		errs = append(errs, errors.New("the combination of the sorted option and streamed selections is not supported."))
+	}
+
+	if op.Stream != nil && op.Policy&DedupeKeepLast != 0 {
+		errs = append(errs, errors.New("the combination of the DedupeKeepLast policy and streamed selections is not supported."))
+	}
+
+	if op.Multi && op.ConfirmSubstitution {
+		errs = append(errs, errors.New("the combination of the confirmSubstitution and multi options is ambiguous."))
+	}
+
 	if err := errors.Join(errs...); err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrConfigurationInvalid, err)
 	}
@@ -110,7 +262,16 @@ func (op *Options) validate() (*set, error) {
 // Flags defines how go-DMenu interacts with DMenu. You can either
 // specify these either using the Option argument to godmenu.Run() or
 // as part of the Configuration structure.
+//
+// Two changes from earlier versions break compatibility. Path is no
+// longer "dmenu" in DefaultFlags, but empty, so that each backend
+// runs its own program. Monitor and WindowID are pointers, which are
+// nil when unset, rather than ints which were unset at -1:
+// MenuMonitor(-1) and MenuWindowID(-1) now fail validation, and are
+// replaced by MenuMonitorUnset and MenuWindowIDUnset.
 type Flags struct {
+	// Path is the launcher executable. When empty, the backend
+	// uses its default program (e.g. "dmenu").
 	Path              string
 	BackgroundColor   string
 	TextColor         string
@@ -121,16 +282,24 @@ type Flags struct {
 	CaseSensitive     bool
 	Bottom            bool
 	Lines             int
-	Monitor           int
-	WindowID          int
+	// Monitor and WindowID, when not nil, place the menu on
+	// the X11 monitor (by index) or window. Use the
+	// MenuMonitor and MenuWindowID arguments to set them.
+	Monitor  *int
+	WindowID *int
 }
 
-func (f *Flags) validate() error {
-	var errs []error
-	if _, err := exec.LookPath(f.Path); err != nil {
-		errs = append(errs, fmt.Errorf("could not find path %q to dmenu: %w", f.Path, err))
+// clone returns a copy of the flags, or nil.
+func (f *Flags) clone() *Flags {
+	if f == nil {
+		return nil
 	}
+	c := *f
+	return &c
+}
 
+func (f *Flags) validate() error {
+	var errs []error
 	if !possiblyValidColor(f.BackgroundColor) {
 		errs = append(errs, fmt.Errorf("invalid background color %s", f.BackgroundColor))
 	}
@@ -145,12 +314,12 @@ func (f *Flags) validate() error {
 	}
 
 	// only 0 or larger values are valid. we don't pass to dmenu
-	// if they're -1 (which is the "unset") value
-	if f.Monitor < -1 {
-		errs = append(errs, fmt.Errorf("invalid X11 montior id '%d'", f.Monitor))
+	// if they're nil (which is the "unset") value
+	if f.Monitor != nil && *f.Monitor < 0 {
+		errs = append(errs, fmt.Errorf("invalid X11 montior id '%d'", *f.Monitor))
 	}
-	if f.WindowID < -1 {
-		errs = append(errs, fmt.Errorf("invalid X11 window id '%d'", f.WindowID))
+	if f.WindowID != nil && *f.WindowID < 0 {
+		errs = append(errs, fmt.Errorf("invalid X11 window id '%d'", *f.WindowID))
 	}
 
 	return errors.Join(errs...)
@@ -174,73 +343,78 @@ func possiblyValidColor(color string) bool {
 	return err == nil
 }
 
-// fillDefault sets any unset fields in the DMenuConfiguration that
-// have the zero value. All of the default values are defined in
-// package constants.
-func (conf *Flags) fillDefault() {
-	conf.Path = loadDefault(conf.Path, DefaultDMenuPath)
-	conf.BackgroundColor = loadDefault(conf.BackgroundColor, DefaultBackgroundColor)
-	conf.TextColor = loadDefault(conf.TextColor, DefaultTextColor)
-	conf.SelectedBgColor = loadDefault(conf.SelectedBgColor, DefaultSelectedBackgroundColor)
-	conf.SelectedTextColor = loadDefault(conf.SelectedTextColor, DefaultSelectedTextColor)
-	conf.Font = loadDefault(conf.Font, DefaultFont)
-	conf.WindowID = -1
-	conf.Monitor = -1
-}
-
-func loadDefault(currentValue, defaultValue string) string {
-	if currentValue != "" {
-		return currentValue
+// hexColor normalizes a '#RGB' or '#RRGGBB' color to 'RRGGBB',
+// appending an opaque alpha channel when alpha is true. X11 color
+// names cannot be converted.
+func hexColor(color string, alpha bool) (string, error) {
+	if !strings.HasPrefix(color, "#") || !possiblyValidColor(color) {
+		return "", fmt.Errorf("color %q is not in '#RGB' or '#RRGGBB' format", color)
 	}
-	return defaultValue
-}
-
-func (conf Flags) args() []string {
-	args := make([]string, 0, 20)
 
-	if !conf.CaseSensitive {
-		args = append(args, "-i")
+	out := color[1:]
+	if len(out) == 3 {
+		out = string([]byte{out[0], out[0], out[1], out[1], out[2], out[2]})
 	}
-
-	if conf.Bottom {
-		args = append(args, "-b")
-	}
-
-	if conf.Lines > 0 {
-		args = append(args, "-l", fmt.Sprint(conf.Lines))
+	if alpha {
+		out += "ff"
 	}
 
-	if conf.Font != "" {
-		args = append(args, "-fn", conf.Font)
-	}
+	return strings.ToLower(out), nil
+}
 
-	if conf.Prompt != "" {
-		args = append(args, "-p", conf.Prompt)
+// pangoFont converts an Xft font name, as used by dmenu (e.g.
+// "Source Code Pro-13" or "monospace:size=10"), into the Pango format
+// (e.g. "Source Code Pro 13") that most other launchers expect.
+func pangoFont(font string) string {
+	name, size := splitFont(font)
+	if size == "" {
+		return name
 	}
+	return name + " " + size
+}
 
-	if conf.BackgroundColor != "" {
-		args = append(args, "-nb", conf.BackgroundColor)
+// fontconfigFont converts an Xft font name into fontconfig's pattern
+// format (e.g. "Source Code Pro:size=13").
+func fontconfigFont(font string) string {
+	name, size := splitFont(font)
+	if size == "" {
+		return name
 	}
+	return name + ":size=" + size
+}
 
-	if conf.SelectedBgColor != "" {
-		args = append(args, "-sb", conf.SelectedBgColor)
+// splitFont separates the family name and size of an Xft font name.
+func splitFont(font string) (name, size string) {
+	name, attrs, _ := strings.Cut(font, ":")
+	for _, attr := range strings.Split(attrs, ":") {
+		if value, ok := strings.CutPrefix(attr, "size="); ok {
+			size = value
+		}
 	}
 
-	if conf.TextColor != "" {
-		args = append(args, "-nf", conf.TextColor)
+	if idx := strings.LastIndexByte(name, '-'); idx > 0 {
+		if _, err := strconv.ParseFloat(name[idx+1:], 64); err == nil {
+			name, size = name[:idx], name[idx+1:]
+		}
 	}
 
-	if conf.SelectedTextColor != "" {
-		args = append(args, "-sf", conf.SelectedTextColor)
-	}
+	return strings.TrimSpace(name), size
+}
 
-	if conf.Monitor > -1 {
-		args = append(args, "-m", fmt.Sprint(conf.Monitor))
-	}
+// fillDefault sets any unset fields in the DMenuConfiguration that
+// have the zero value. All of the default values are defined in
+// package constants.
+func (conf *Flags) fillDefault() {
+	conf.BackgroundColor = loadDefault(conf.BackgroundColor, DefaultBackgroundColor)
+	conf.TextColor = loadDefault(conf.TextColor, DefaultTextColor)
+	conf.SelectedBgColor = loadDefault(conf.SelectedBgColor, DefaultSelectedBackgroundColor)
+	conf.SelectedTextColor = loadDefault(conf.SelectedTextColor, DefaultSelectedTextColor)
+	conf.Font = loadDefault(conf.Font, DefaultFont)
+}
 
-	if conf.WindowID > -1 {
-		args = append(args, "-w", fmt.Sprint(conf.WindowID))
+func loadDefault(currentValue, defaultValue string) string {
+	if currentValue != "" {
+		return currentValue
 	}
-
-	return args
+	return defaultValue
 }
@@ -0,0 +1,254 @@
+package godmenu
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	zero, one, window := 0, 1, 4242
+
+	// every flag, set to a value other than its default.
+	flags := func() *Flags {
+		return &Flags{
+			Path:              "my-dmenu",
+			BackgroundColor:   "#111111",
+			TextColor:         "#222222",
+			SelectedBgColor:   "#333333",
+			SelectedTextColor: "#444444",
+			Font:              "Mono-9",
+			Prompt:            "go",
+			CaseSensitive:     true,
+			Bottom:            true,
+			Lines:             7,
+			Monitor:           &one,
+			WindowID:          &window,
+		}
+	}
+	expected := []string{
+		"-b", "-l", "7", "-fn", "Mono-9", "-p", "go",
+		"-nb", "#111111", "-sb", "#333333", "-nf", "#222222", "-sf", "#444444",
+		"-m", "1", "-w", "4242",
+	}
+	defaults := []string{
+		"-i", "-fn", DefaultFont,
+		"-nb", DefaultBackgroundColor, "-sb", DefaultSelectedBackgroundColor,
+		"-nf", DefaultTextColor, "-sf", DefaultSelectedTextColor,
+	}
+
+	check := func(t *testing.T, runner *mockRunner, program string, args []string) {
+		t.Helper()
+		if runner.cmd.Program != program {
+			t.Errorf("ran %q, expected %q", runner.cmd.Program, program)
+		}
+		if !slices.Equal(runner.cmd.Args, args) {
+			t.Errorf("got %q\nexpected %q", runner.cmd.Args, args)
+		}
+	}
+
+	t.Run("Run", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one"}
+		_, err := Run(t.Context(), WithRunner(runner), Items("one"),
+			DMenuPath("my-dmenu"),
+			BackgroundColor("#111111"),
+			TextColor("#222222"),
+			SelectedBgColor("#333333"),
+			SelectedText("#444444"),
+			func(o *Options) { o.Flags.Font = "Mono-9" },
+			MenuPrompt("go"),
+			CaseSensitive(),
+			MenuBottom(),
+			MenuLines(7),
+			MenuMonitor(1),
+			MenuWindowID(4242),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, runner, "my-dmenu", expected)
+	})
+	t.Run("Do", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one"}
+		_, err := Do(t.Context(), Options{Selections: []string{"one"}, Runner: runner, Flags: flags()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, runner, "my-dmenu", expected)
+	})
+	t.Run("WithFlags", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one"}
+		if _, err := Run(t.Context(), WithRunner(runner), Items("one"), WithFlags(flags())); err != nil {
+			t.Fatal(err)
+		}
+		check(t, runner, "my-dmenu", expected)
+	})
+	t.Run("DefaultFlags", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one"}
+		if _, err := Run(t.Context(), WithRunner(runner), Items("one"), WithFlags(DefaultFlags())); err != nil {
+			t.Fatal(err)
+		}
+		check(t, runner, DefaultDMenuPath, defaults)
+
+		conf := DefaultFlags()
+		if conf.Monitor != nil || conf.WindowID != nil || conf.Path != "" || conf.Lines != 0 || conf.Prompt != "" {
+			t.Errorf("%+v", conf)
+		}
+		conf.Prompt = "changed"
+		if DefaultFlags().Prompt != "" {
+			t.Error("default flags are shared")
+		}
+	})
+	t.Run("Defaults", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one"}
+		if _, err := Do(t.Context(), Options{Selections: []string{"one"}, Runner: runner}); err != nil {
+			t.Fatal(err)
+		}
+		check(t, runner, DefaultDMenuPath, defaults)
+	})
+	t.Run("MonitorZero", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one"}
+		_, err := Do(t.Context(), Options{Selections: []string{"one"}, Runner: runner, Flags: &Flags{Monitor: &zero, WindowID: &zero}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, runner, DefaultDMenuPath, append(slices.Clone(defaults), "-m", "0", "-w", "0"))
+	})
+	t.Run("Unset", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one"}
+		_, err := Run(t.Context(), WithRunner(runner), Items("one"), MenuMonitor(1), MenuWindowID(2), MenuMonitorUnset(), MenuWindowIDUnset())
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, runner, DefaultDMenuPath, defaults)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := Run(t.Context(), WithRunner(&mockRunner{}), Items("one"), MenuMonitor(-1), MenuWindowID(-2))
+		if err == nil {
+			t.Fatal("negative monitor and window ids should be invalid")
+		}
+	})
+}
+
+// lastRunner selects the last of the rendered selections, and is
+// safe to share between menus.
+type lastRunner struct{}
+
+func (lastRunner) LookPath(program string) (string, error) { return program, nil }
+
+func (lastRunner) Run(_ context.Context, cmd Command) ([]byte, []byte, error) {
+	data, err := io.ReadAll(cmd.Stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(data[bytes.LastIndexByte(data, '\n')+1:], '\n'), nil, nil
+}
+
+func TestOptions(t *testing.T) {
+	concurrently := func(t *testing.T, fn func(t *testing.T, n int)) {
+		t.Helper()
+		var wg sync.WaitGroup
+		for n := range 16 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 8 {
+					fn(t, n)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	t.Run("Unmodified", func(t *testing.T) {
+		selections := []string{"  one ", "two\n", "", "two", "three\tfour"}
+		flags := &Flags{Prompt: "go"}
+		opts := Options{Selections: selections, Flags: flags, Runner: lastRunner{}, Policy: DropBlanks | DedupeKeepLast | CollapseWhitespace}
+
+		out, err := Do(t.Context(), opts)
+		if err != nil || out != "three four" {
+			t.Fatal(out, err)
+		}
+		if !slices.Equal(opts.Selections, []string{"  one ", "two\n", "", "two", "three\tfour"}) {
+			t.Errorf("%q", opts.Selections)
+		}
+		if *flags != (Flags{Prompt: "go"}) {
+			t.Errorf("%+v", flags)
+		}
+	})
+	t.Run("Args", func(t *testing.T) {
+		base := ResolveOptions(Items("a", "b"), WithItems(MakeItem("c", "C")), WithEnv("A=1"))
+		base.Selections = slices.Grow(base.Selections, 8)
+		base.Items = slices.Grow(base.Items, 8)
+
+		opts := ResolveOptions(WithOptions(base), Items("d"), WithItems(MakeItem("e", "E")), WithEnv("B=2"), MenuPrompt("changed"))
+		if len(base.Selections) != 2 || len(base.Items) != 1 || len(base.Env) != 1 || base.Flags.Prompt != "" {
+			t.Errorf("%+v", base)
+		}
+		if len(opts.Selections) != 3 || len(opts.Items) != 2 || len(opts.Env) != 2 || opts.Flags.Prompt != "changed" {
+			t.Errorf("%+v", opts)
+		}
+
+		opts = ResolveOptions(WithOptions(&Options{Selections: []string{"a"}}), MenuPrompt("x"))
+		if opts.Flags == nil || opts.Flags.Prompt != "x" {
+			t.Errorf("%+v", opts)
+		}
+
+		flags := &Flags{Prompt: "shared"}
+		_ = ResolveOptions(WithFlags(flags), MenuPrompt("changed"), MenuLines(3))
+		if *flags != (Flags{Prompt: "shared"}) {
+			t.Errorf("%+v", flags)
+		}
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		opts := Options{
+			Selections: []string{" a ", "b", "c\n"},
+			Items:      []Item{MakeItem("d", "D")},
+			Sources:    []Source{StaticSource("more", "e", "f")},
+			Flags:      &Flags{Prompt: "pick"},
+			Runner:     lastRunner{},
+		}
+
+		concurrently(t, func(t *testing.T, n int) {
+			res, err := DoResult(t.Context(), opts)
+			if err != nil || res.Value != "f" || res.Index != 5 || res.Source != "more" {
+				t.Error(n, res, err)
+			}
+		})
+
+		if opts.Selections[0] != " a " || len(opts.Items) != 1 || opts.Flags.Font != "" {
+			t.Errorf("%+v", opts)
+		}
+	})
+	t.Run("ConcurrentArgs", func(t *testing.T) {
+		base := ResolveOptions(Items("a", "b"), WithRunner(lastRunner{}), Sorted())
+		base.Selections = slices.Grow(base.Selections, 64)
+
+		concurrently(t, func(t *testing.T, n int) {
+			sel := fmt.Sprint("z", n)
+			out, err := Run(t.Context(), WithOptions(base), Items(sel), MenuPrompt(sel), MenuMonitor(n))
+			if err != nil || out != sel {
+				t.Error(n, out, err)
+			}
+		})
+
+		if len(base.Selections) != 2 || base.Flags.Prompt != "" || base.Flags.Monitor != nil {
+			t.Errorf("%+v", base)
+		}
+	})
+	t.Run("ConcurrentSelect", func(t *testing.T) {
+		items := make([]Item, 1, 64)
+		items[0] = MakeItem("first", "1")
+
+		concurrently(t, func(t *testing.T, n int) {
+			out, err := Select(t.Context(), []int{n}, func(n int) string { return fmt.Sprint("n", n) }, SetItems(items), WithRunner(lastRunner{}))
+			if err != nil || out != n {
+				t.Error(n, out, err)
+			}
+		})
+	})
+}
@@ -0,0 +1,163 @@
+package godmenu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+)
+
+// Detector chooses a backend based on the environment that the
+// program runs in: Wayland or X11 sessions, and terminals (including
+// tmux and SSH sessions). Within each kind of session, backends are
+// considered in order, and the first that passes validation (e.g. is
+// installed) is used.
+type Detector struct {
+	// Wayland lists the backends to consider when
+	// WAYLAND_DISPLAY is set.
+	Wayland []Backend
+	// X11 lists the backends to consider when DISPLAY is set,
+	// which includes XWayland under Wayland sessions.
+	X11 []Backend
+	// Terminal lists the backends to consider when standard input
+	// is a terminal. In tmux or SSH sessions, these are preferred
+	// to graphical launchers. The built-in Picker makes a useful
+	// last resort.
+	Terminal []Backend
+	// Getenv and IsTerminal, when set, replace os.Getenv and the
+	// check that standard input is a terminal.
+	Getenv     func(string) string
+	IsTerminal func() bool
+}
+
+// DefaultDetector returns a Detector with godmenu's default
+// preferences.
+func DefaultDetector() *Detector {
+	return &Detector{
+		Wayland:  []Backend{Fuzzel(), Tofi(), Wofi(), Bemenu(), Wmenu()},
+		X11:      []Backend{DMenu(), Rofi(), Bemenu()},
+		Terminal: []Backend{Fzf{}, Picker()},
+	}
+}
+
+// Detect chooses a backend using the default detector.
+func Detect(opts *Options) (Backend, error) { return DefaultDetector().Detect(opts) }
+
+// AutoBackend sets the backend using the default detector. If no
+// backend is usable, running the menu fails validation.
+func AutoBackend() Arg { return DetectBackend(DefaultDetector()) }
+
+// DetectBackend sets the backend using the detector, which chooses
+// the backend when the menu runs, so that the options which follow
+// (e.g. WithRunner and WithEnv) are considered. The detected backend
+// runs its default program, regardless of the Path in the options'
+// Flags. If no backend is usable, running the menu fails validation.
+func DetectBackend(d *Detector) Arg { return func(o *Options) { o.Backend = detected{d} } }
+
+// Detect returns the first usable backend for the environment.
+// Backends are validated using their default program, regardless of
+// the Path in the options' Flags.
+func (d *Detector) Detect(opts *Options) (Backend, error) {
+	probe := *opts
+	probe.Flags = nil
+	if opts.Flags != nil {
+		flags := *opts.Flags
+		flags.Path = ""
+		probe.Flags = &flags
+	}
+	probe.flags()
+
+	candidates := d.candidates()
+	if len(candidates) == 0 {
+		return nil, errors.New("no display or terminal detected")
+	}
+
+	errs := make([]error, 0, len(candidates))
+	for _, backend := range candidates {
+		err := backend.Validate(&probe)
+		if err == nil {
+			return backend, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, fmt.Errorf("no usable menu backend: %w", errors.Join(errs...))
+}
+
+func (d *Detector) candidates() []Backend {
+	getenv := d.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	isTerminal := d.IsTerminal
+	if isTerminal == nil {
+		isTerminal = stdinIsTerminal
+	}
+
+	var graphical, terminal []Backend
+	if getenv("WAYLAND_DISPLAY") != "" {
+		graphical = append(graphical, d.Wayland...)
+	}
+	if getenv("DISPLAY") != "" {
+		graphical = append(graphical, d.X11...)
+	}
+	if isTerminal() {
+		terminal = d.Terminal
+	}
+
+	remote := getenv("TMUX") != "" || getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != ""
+	if remote {
+		return slices.Concat(terminal, graphical)
+	}
+
+	return slices.Concat(graphical, terminal)
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// detected is the backend set by DetectBackend, which validation
+// replaces with the detector's choice.
+type detected struct{ *Detector }
+
+var errUndetected = errors.New("the backend is detected when the menu runs")
+
+func (detected) Name() string                             { return "detected" }
+func (d detected) Validate(opts *Options) error           { _, err := d.Detect(opts); return err }
+func (detected) Render(io.Writer, iter.Seq[string]) error { return errUndetected }
+func (detected) Parse([]byte, error) (Response, error)    { return Response{}, errUndetected }
+
+func (detected) Run(context.Context, *Options, io.Reader) ([]byte, error) { return nil, errUndetected }
+
+// detect replaces a detected backend with the detector's choice. The
+// detector ignores the Path in the flags, which would otherwise run
+// in place of the chosen backend's program, and so it is cleared.
+func (op *Options) detect() {
+	d, ok := op.Backend.(detected)
+	if !ok {
+		return
+	}
+
+	backend, err := d.Detect(op)
+	if err != nil {
+		backend = unavailable{err: err}
+	}
+	op.Backend = backend
+	op.Flags.Path = ""
+}
+
+// unavailable is the backend set when detection fails, so that the
+// error is reported when the menu runs.
+type unavailable struct{ err error }
+
+func (unavailable) Name() string                               { return "unavailable" }
+func (u unavailable) Validate(*Options) error                  { return u.err }
+func (u unavailable) Render(io.Writer, iter.Seq[string]) error { return u.err }
+func (u unavailable) Parse([]byte, error) (Response, error)    { return Response{}, u.err }
+
+func (u unavailable) Run(context.Context, *Options, io.Reader) ([]byte, error) { return nil, u.err }
@@ -0,0 +1,127 @@
+package godmenu
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	// installed sets PATH to a directory containing only the named
+	// programs, and the cat that the stand-in launcher runs.
+	installed := func(t *testing.T, programs ...string) {
+		t.Helper()
+		path, _ := standIn(t, "", 0)
+		dir := t.TempDir()
+		if cat, err := exec.LookPath("cat"); err == nil {
+			if err := os.Symlink(cat, filepath.Join(dir, "cat")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, name := range programs {
+			if err := os.Symlink(path, filepath.Join(dir, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		t.Setenv("PATH", dir)
+	}
+	environment := func(env map[string]string, tty bool) *Detector {
+		d := DefaultDetector()
+		d.Getenv = func(k string) string { return env[k] }
+		d.IsTerminal = func() bool { return tty }
+		return d
+	}
+
+	for _, tt := range []struct {
+		name      string
+		programs  []string
+		env       map[string]string
+		tty       bool
+		expected  string
+		preferred func(*Detector)
+	}{
+		{name: "X11", programs: []string{"dmenu", "rofi", "fzf"}, env: map[string]string{"DISPLAY": ":0"}, tty: true, expected: "dmenu"},
+		{name: "X11Fallback", programs: []string{"rofi"}, env: map[string]string{"DISPLAY": ":0"}, expected: "rofi"},
+		{name: "Wayland", programs: []string{"dmenu", "tofi", "wofi"}, env: map[string]string{"DISPLAY": ":0", "WAYLAND_DISPLAY": "wayland-1"}, expected: "tofi"},
+		{name: "XWayland", programs: []string{"dmenu"}, env: map[string]string{"DISPLAY": ":0", "WAYLAND_DISPLAY": "wayland-1"}, expected: "dmenu"},
+		{name: "Terminal", programs: []string{"dmenu", "fzf"}, tty: true, expected: "fzf"},
+		{name: "GraphicalTerminal", programs: []string{"dmenu", "fzf"}, env: map[string]string{"DISPLAY": ":0"}, tty: true, expected: "dmenu"},
+		{name: "Tmux", programs: []string{"dmenu", "fzf"}, env: map[string]string{"DISPLAY": ":0", "TMUX": "/tmp/tmux"}, tty: true, expected: "fzf"},
+		{name: "SSH", programs: []string{"dmenu", "fzf"}, env: map[string]string{"DISPLAY": "localhost:10", "SSH_CONNECTION": "10.0.0.1"}, tty: true, expected: "fzf"},
+		{name: "SSHWithoutTerminal", programs: []string{"dmenu", "fzf"}, env: map[string]string{"DISPLAY": "localhost:10", "SSH_CONNECTION": "10.0.0.1"}, expected: "dmenu"},
+		{
+			name:     "Preference",
+			programs: []string{"dmenu", "rofi"},
+			env:      map[string]string{"DISPLAY": ":0"},
+			expected: "rofi",
+			preferred: func(d *Detector) {
+				d.X11 = []Backend{Rofi(), DMenu()}
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			installed(t, tt.programs...)
+			d := environment(tt.env, tt.tty)
+			if tt.preferred != nil {
+				tt.preferred(d)
+			}
+
+			backend, err := d.Detect(ResolveOptions(DMenuPath("/not/dmenu")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if backend.Name() != tt.expected {
+				t.Error(backend.Name())
+			}
+
+			// the detected backend runs its own program, not
+			// the path, which the detector ignored.
+			res, err := RunResult(t.Context(), DetectBackend(d), DMenuPath("/not/dmenu"), Items("one"))
+			if res == nil || res.Backend != tt.expected {
+				t.Error(res, err)
+			}
+		})
+	}
+	t.Run("Runner", func(t *testing.T) {
+		installed(t)
+		d := environment(map[string]string{"DISPLAY": ":0"}, false)
+
+		runner := &mockRunner{stdout: "two\n"}
+		out, err := Run(t.Context(), DetectBackend(d), WithRunner(runner), Items("one", "two"))
+		if err != nil || out != "two" {
+			t.Fatal(out, err)
+		}
+		if runner.cmd.Program != "dmenu" {
+			t.Errorf("%+v", runner.cmd)
+		}
+
+		runner = &mockRunner{missing: exec.ErrNotFound}
+		_, err = Run(t.Context(), DetectBackend(d), WithRunner(runner), Items("one", "two"))
+		if !errors.Is(err, ErrConfigurationInvalid) || !errors.Is(err, exec.ErrNotFound) || len(runner.lookups) == 0 {
+			t.Error(err, runner.lookups)
+		}
+	})
+	t.Run("Unavailable", func(t *testing.T) {
+		installed(t, "fzf")
+		d := environment(map[string]string{"DISPLAY": ":0"}, false)
+
+		if backend, err := d.Detect(ResolveOptions()); err == nil {
+			t.Error(backend.Name())
+		}
+
+		out, err := Run(t.Context(), DetectBackend(d), Items("one", "two"))
+		if !errors.Is(err, ErrConfigurationInvalid) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Headless", func(t *testing.T) {
+		installed(t, "dmenu", "fzf")
+		d := environment(nil, false)
+
+		if backend, err := d.Detect(ResolveOptions()); err == nil {
+			t.Error(backend.Name())
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package godmenu
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	t.Run("Rejected", func(t *testing.T) {
+		_, err := Run(t.Context(), WithBackend(&mockBackend{output: "a"}), Items("a", "b", "a"))
+		if !errors.Is(err, ErrConfigurationInvalid) {
+			t.Error(err)
+		}
+	})
+	t.Run("Suffixed", func(t *testing.T) {
+		mock := &mockBackend{output: "a (2)\n"}
+		res, err := RunResult(t.Context(), WithBackend(mock), Items("a", "b", "a"), AllowDuplicateSelections(), RequireMatch())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "a\nb\na (2)" {
+			t.Errorf("%q", mock.input)
+		}
+		if res.Value != "a" || res.Index != 2 || !res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("First", func(t *testing.T) {
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{output: "a"}), Items("a", "b", "a"), AllowDuplicateSelections())
+		if err != nil || res.Value != "a" || res.Index != 0 {
+			t.Error(res, err)
+		}
+	})
+	t.Run("Collision", func(t *testing.T) {
+		mock := &mockBackend{output: "a (3)"}
+		res, err := RunResult(t.Context(), WithBackend(mock), Items("a", "a (2)", "a"), AllowDuplicateSelections())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "a\na (2)\na (3)" || res.Value != "a" || res.Index != 2 {
+			t.Errorf("%q %+v", mock.input, res)
+		}
+	})
+	t.Run("Items", func(t *testing.T) {
+		mock := &mockBackend{output: "x (2)"}
+		res, err := RunResult(t.Context(), WithBackend(mock), WithItems(MakeItem("x", "1"), MakeItem("x", "1")), AllowDuplicateSelections())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "x\nx (2)" || res.Value != "1" || res.Index != 1 {
+			t.Errorf("%q %+v", mock.input, res)
+		}
+	})
+	t.Run("Indexed", func(t *testing.T) {
+		runner := &mockRunner{stdout: "2 a\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), WithBackend(Rofi()), Items("a", "b", "a"), AllowDuplicateSelections())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "a\nb\na" {
+			t.Errorf("%q", runner.stdin)
+		}
+		if res.Value != "a" || res.Index != 2 || !res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("IndexedSorted", func(t *testing.T) {
+		runner := &mockRunner{stdout: "1 a\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), WithBackend(Rofi()), Items("b", "a", "a"), AllowDuplicateSelections(), Sorted())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "a\na\nb" || res.Value != "a" || res.Index != 2 {
+			t.Errorf("%q %+v", runner.stdin, res)
+		}
+	})
+	t.Run("IndexedFreeText", func(t *testing.T) {
+		runner := &mockRunner{stdout: "-1 zed\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), WithBackend(Rofi()), Items("a", "b", "a"), AllowDuplicateSelections())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "zed" || res.Index != -1 || res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("IndexedMulti", func(t *testing.T) {
+		runner := &mockRunner{stdout: "0 a\n2 a\n"}
+		out, err := RunMulti(t.Context(), WithRunner(runner), WithBackend(Rofi()), WithItems(MakeItem("a", "first"), MakeItem("b", "b"), MakeItem("a", "first")), AllowDuplicateSelections())
+		if err != nil || !slices.Equal(out, []string{"first", "first"}) {
+			t.Error(out, err)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package godmenu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// DmenuError describes a launcher which failed, as opposed to one
+// which the user dismissed (ErrCanceled), or which was stopped when
+// the menu's context ended (ErrTimeout). DmenuErrors are
+// ErrDmenuFailure errors, and unwrap to the underlying error (e.g. an
+// *exec.ExitError).
+type DmenuError struct {
+	// Backend is the name of the backend which failed.
+	Backend string
+	// ExitCode is the launcher's exit status, and is -1 when it
+	// did not exit normally, for instance when it was killed.
+	ExitCode int
+	// Signal is the signal which terminated the launcher, if any.
+	Signal os.Signal
+	// Stderr holds what the launcher wrote to standard error.
+	Stderr string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *DmenuError) Unwrap() error        { return e.Err }
+func (e *DmenuError) Is(target error) bool { return target == ErrDmenuFailure }
+
+func (e *DmenuError) Error() string {
+	var msg string
+	switch {
+	case e.Signal != nil:
+		msg = fmt.Sprintf("%s was terminated by signal %q", e.Backend, e.Signal)
+	case e.ExitCode >= 0:
+		msg = fmt.Sprintf("%s exited with code %d", e.Backend, e.ExitCode)
+	default:
+		msg = fmt.Sprintf("%s failed: %v", e.Backend, e.Err)
+	}
+
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg = fmt.Sprintf("%s [%s]", msg, stderr)
+	}
+
+	return msg
+}
+
+// newDmenuError describes the failure of a launcher from the error
+// returned by running it.
+func newDmenuError(backend string, err error, stderr []byte) *DmenuError {
+	derr := &DmenuError{Backend: backend, ExitCode: -1, Stderr: string(stderr), Err: err}
+
+	if code, ok := exitCode(err); ok {
+		derr.ExitCode = code
+	}
+
+	var exerr *exec.ExitError
+	if errors.As(err, &exerr) {
+		if status, ok := exerr.Sys().(interface {
+			Signaled() bool
+			Signal() syscall.Signal
+		}); ok && status.Signaled() {
+			derr.Signal = status.Signal()
+		}
+	}
+
+	return derr
+}
+
+// launchError returns the error from running a launcher as a
+// DmenuError, if it is not one already.
+func launchError(backend string, err error) error {
+	if derr := (*DmenuError)(nil); errors.As(err, &derr) {
+		return err
+	}
+	return newDmenuError(backend, err, nil)
+}
+
+// interrupted describes a launcher which was stopped because the
+// menu's context ended. It keeps the launcher's error, as a
+// DmenuError does, but the launcher did not fail, so it is not an
+// ErrDmenuFailure error.
+type interrupted struct{ launch *DmenuError }
+
+func (e *interrupted) Error() string { return e.launch.Error() }
+func (e *interrupted) Unwrap() error { return e.launch.Err }
+
+// interrupt returns the error from running a launcher which was
+// stopped because the menu's context ended.
+func interrupt(err error) error {
+	if derr := (*DmenuError)(nil); errors.As(err, &derr) {
+		return &interrupted{launch: derr}
+	}
+	return err
+}
+
+// stderrOf returns what a failed launcher wrote to standard error.
+func stderrOf(err error) string {
+	if derr := (*DmenuError)(nil); errors.As(err, &derr) {
+		return strings.TrimSpace(derr.Stderr)
+	}
+	return ""
+}
+
+// canceled is the error for a menu which the user dismissed. For
+// compatibility, it is also an ErrSelectionMissing error. Anything
+// that the launcher wrote to standard error (e.g. a warning) is
+// included in the message.
+func canceled(backend string, err error) error {
+	if stderr := stderrOf(err); stderr != "" {
+		return fmt.Errorf("%s was dismissed [%s]: %w: %w", backend, stderr, ErrCanceled, ErrSelectionMissing)
+	}
+	return fmt.Errorf("%s was dismissed: %w: %w", backend, ErrCanceled, ErrSelectionMissing)
+}
@@ -0,0 +1,189 @@
+package godmenu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestErrors(t *testing.T) {
+	// sleeper writes a launcher which never returns on its own.
+	sleeper := func(t *testing.T) string {
+		t.Helper()
+		standIn(t, "", 0) // skips without a shell
+		path := filepath.Join(t.TempDir(), "dmenu")
+		if err := os.WriteFile(path, []byte("#!/bin/sh\nexec sleep 10\n"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("Canceled", func(t *testing.T) {
+		path, _ := standIn(t, "", 1)
+
+		out, err := Run(t.Context(), DMenuPath(path), Items("one", "two"))
+		if !errors.Is(err, ErrCanceled) || !errors.Is(err, ErrSelectionMissing) || errors.Is(err, ErrDmenuFailure) || out != "" {
+			t.Error(out, err)
+		}
+		var derr *DmenuError
+		if errors.As(err, &derr) {
+			t.Error("cancellation is not a failure", derr)
+		}
+	})
+	t.Run("Failure", func(t *testing.T) {
+		path, _ := standInWithStderr(t, "", "cannot open display\n", 2)
+
+		out, err := Run(t.Context(), DMenuPath(path), Items("one", "two"))
+		if errors.Is(err, ErrCanceled) || errors.Is(err, ErrSelectionMissing) || !errors.Is(err, ErrDmenuFailure) || out != "" {
+			t.Error(out, err)
+		}
+
+		var derr *DmenuError
+		if !errors.As(err, &derr) {
+			t.Fatal(err)
+		}
+		if derr.Backend != "dmenu" || derr.ExitCode != 2 || derr.Signal != nil || derr.Stderr != "cannot open display\n" {
+			t.Errorf("%+v", derr)
+		}
+		if err.Error() != "dmenu exited with code 2 [cannot open display]" {
+			t.Error(err)
+		}
+	})
+	t.Run("Stderr", func(t *testing.T) {
+		t.Run("Warning", func(t *testing.T) {
+			path, _ := standInWithStderr(t, "two\n", "warning: font not found\n", 0)
+
+			var backend, warning string
+			out, err := Run(t.Context(), DMenuPath(path), Items("one", "two"), RequireMatch(),
+				WithStderrHook(func(b, stderr string) { backend, warning = b, stderr }))
+			if err != nil || out != "two" {
+				t.Fatal(out, err)
+			}
+			if backend != "dmenu" || warning != "warning: font not found\n" {
+				t.Errorf("%q %q", backend, warning)
+			}
+		})
+		t.Run("Quiet", func(t *testing.T) {
+			path, _ := standIn(t, "two\n", 0)
+
+			called := false
+			if _, err := Run(t.Context(), DMenuPath(path), Items("one", "two"), WithStderrHook(func(string, string) { called = true })); err != nil {
+				t.Fatal(err)
+			}
+			if called {
+				t.Error("hook called without output on stderr")
+			}
+		})
+		t.Run("EscapeWithWarning", func(t *testing.T) {
+			path, _ := standInWithStderr(t, "", "warning: font fallback\n", 1)
+
+			var warning string
+			_, err := Run(t.Context(), DMenuPath(path), Items("one", "two"), WithStderrHook(func(_, stderr string) { warning = stderr }))
+			if !errors.Is(err, ErrCanceled) || errors.Is(err, ErrDmenuFailure) {
+				t.Error(err)
+			}
+			if warning != "warning: font fallback\n" || !strings.Contains(err.Error(), "font fallback") {
+				t.Error(err, warning)
+			}
+		})
+		t.Run("Failure", func(t *testing.T) {
+			path, _ := standInWithStderr(t, "", "cannot open display\n", 2)
+
+			var warning string
+			_, err := Run(t.Context(), DMenuPath(path), Items("one", "two"), WithStderrHook(func(_, stderr string) { warning = stderr }))
+			var derr *DmenuError
+			if !errors.As(err, &derr) || derr.Stderr != "cannot open display\n" || warning != derr.Stderr {
+				t.Error(err, warning)
+			}
+		})
+		t.Run("RofiFailure", func(t *testing.T) {
+			path, _ := standInWithStderr(t, "", "unknown option\n", 2)
+
+			_, err := Run(t.Context(), WithBackend(Rofi()), DMenuPath(path), Items("one", "two"))
+			var derr *DmenuError
+			if !errors.As(err, &derr) || derr.Backend != "rofi" || derr.ExitCode != 2 || derr.Stderr != "unknown option\n" {
+				t.Error(err)
+			}
+		})
+	})
+	t.Run("ExitCode", func(t *testing.T) {
+		path, _ := standIn(t, "", 3)
+
+		_, err := Run(t.Context(), WithBackend(Bemenu()), DMenuPath(path), Items("one", "two"))
+		var derr *DmenuError
+		if !errors.As(err, &derr) || derr.ExitCode != 3 || derr.Backend != "bemenu" {
+			t.Error(err)
+		}
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		t.Cleanup(cancel)
+
+		res, err := RunResult(ctx, DMenuPath(sleeper(t)), Items("one", "two"))
+		if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCanceled) {
+			t.Error(res, err)
+		}
+		if errors.Is(err, ErrDmenuFailure) || errors.As(err, new(*DmenuError)) {
+			t.Error("a timeout is not a launcher failure", err)
+		}
+		if !strings.Contains(err.Error(), "killed") || res == nil || res.ExitCode != -1 || res.Value != "" {
+			t.Error(res, err)
+		}
+	})
+	t.Run("ContextCanceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		out, err := Run(ctx, DMenuPath(sleeper(t)), Items("one", "two"))
+		if !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) || errors.Is(err, ErrCanceled) || out != "" {
+			t.Error(out, err)
+		}
+		if errors.Is(err, ErrDmenuFailure) {
+			t.Error("a canceled menu is not a launcher failure", err)
+		}
+	})
+	t.Run("Backends", func(t *testing.T) {
+		for _, tt := range []struct {
+			backend Backend
+			code    int
+		}{
+			{Rofi(), 1},
+			{Fzf{}, 130},
+			{Fuzzel(), 1},
+			{Wofi(), 1},
+		} {
+			path, _ := standIn(t, "", tt.code)
+			_, err := Run(t.Context(), WithBackend(tt.backend), DMenuPath(path), Items("one", "two"))
+			if !errors.Is(err, ErrCanceled) {
+				t.Error(tt.backend.Name(), err)
+			}
+		}
+
+		_, err := Run(t.Context(), WithBackend(Plain(strings.NewReader("\n"), &bytes.Buffer{})), Items("one"))
+		if !errors.Is(err, ErrCanceled) {
+			t.Error(err)
+		}
+
+		_, err = newPicker([]string{"one"}, ResolveOptions()).run(strings.NewReader("\x1b"), &bytes.Buffer{})
+		if !errors.Is(err, ErrCanceled) {
+			t.Error(err)
+		}
+	})
+	t.Run("Message", func(t *testing.T) {
+		for expected, derr := range map[string]*DmenuError{
+			`rofi was terminated by signal "killed"`:  {Backend: "rofi", ExitCode: -1, Signal: syscall.SIGKILL},
+			"fzf exited with code 2 [unknown option]": {Backend: "fzf", ExitCode: 2, Stderr: "unknown option\n"},
+			"dmenu failed: exec: not found":           {Backend: "dmenu", ExitCode: -1, Err: errors.New("exec: not found")},
+		} {
+			if derr.Error() != expected {
+				t.Errorf("%q", derr.Error())
+			}
+		}
+	})
+}
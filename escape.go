@@ -0,0 +1,64 @@
+package godmenu
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// escape returns the form of a selection that the menu shows, which
+// is one line of valid UTF-8: line breaks, tabs and other control
+// characters, and invalid bytes, are shown as Go escape sequences
+// (e.g. "\n"). Backslashes are not escaped, so selections which
+// differ only in an escaped character and its escape sequence are
+// reported as duplicates.
+func escape(sel string) string {
+	if !needsEscape(sel) {
+		return sel
+	}
+
+	var b strings.Builder
+	for idx := 0; idx < len(sel); {
+		r, size := utf8.DecodeRuneInString(sel[idx:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, sel[idx])
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < utf8.RuneSelf && unicode.IsControl(r):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case unescaped(r):
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+		idx += size
+	}
+	return b.String()
+}
+
+func needsEscape(sel string) bool {
+	// most selections are printable ASCII, which never needs
+	// escaping: only decode the rest of a selection from its
+	// first other byte.
+	for idx := 0; idx < len(sel); idx++ {
+		switch c := sel[idx]; {
+		case c >= utf8.RuneSelf:
+			sel = sel[idx:]
+			return !utf8.ValidString(sel) || strings.ContainsFunc(sel, func(r rune) bool { return !unescaped(r) })
+		case c < ' ' || c == 0x7f:
+			return true
+		}
+	}
+	return false
+}
+
+// unescaped reports if the menu can show the character as it is.
+func unescaped(r rune) bool {
+	return !unicode.IsControl(r) && !unicode.In(r, unicode.Zl, unicode.Zp)
+}
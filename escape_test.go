@@ -0,0 +1,124 @@
+package godmenu
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	t.Run("Display", func(t *testing.T) {
+		for in, out := range map[string]string{
+			"plain":          "plain",
+			`C:\path`:        `C:\path`,
+			"one\ntwo":       `one\ntwo`,
+			"col\tcol\r\n":   `col\tcol\r\n`,
+			"bell\a":         `bell\x07`,
+			"\x1b[1mbold":    `\x1b[1mbold`,
+			"bad\xffbyte":    `bad\xffbyte`,
+			"para\u2029next": `para\u2029next`,
+			"nel\u0085":      `nel\u0085`,
+			"héllo, 世界":      "héllo, 世界",
+		} {
+			if got := escape(in); got != out {
+				t.Errorf("%q became %q, expected %q", in, got, out)
+			}
+		}
+	})
+	t.Run("MultiLine", func(t *testing.T) {
+		mock := &mockBackend{output: `first\nline`}
+		res, err := RunResult(t.Context(), WithBackend(mock), Items("first\nline", "second\tcolumn", "third"), RequireMatch())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != `first\nline`+"\n"+`second\tcolumn`+"\nthird" {
+			t.Errorf("%q", mock.input)
+		}
+		if res.Value != "first\nline" || res.Index != 0 || !res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("Items", func(t *testing.T) {
+		mock := &mockBackend{output: `note\n(draft)`}
+		out, err := Run(t.Context(), WithBackend(mock), WithItems(MakeItem("note\n(draft)", "notes/draft.md")))
+		if err != nil || out != "notes/draft.md" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Multi", func(t *testing.T) {
+		mock := &mockBackend{output: "a\\nb\nc\n"}
+		out, err := RunMulti(t.Context(), WithBackend(mock), Items("a\nb", "c"))
+		if err != nil || len(out) != 2 || out[0] != "a\nb" || out[1] != "c" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Collision", func(t *testing.T) {
+		_, err := Run(t.Context(), WithBackend(&mockBackend{}), Items("a\nb", `a\nb`))
+		if !errors.Is(err, ErrConfigurationInvalid) {
+			t.Error(err)
+		}
+	})
+}
+
+func FuzzRendered(f *testing.F) {
+	f.Add("one", "two", false)
+	f.Add("one\ntwo", "three\tfour", true)
+	f.Add("\xff\xfe", "\x00\r\n", false)
+	f.Add("  padded  ", "\u2028", true)
+
+	f.Fuzz(func(t *testing.T, a, b string, sorted bool) {
+		st := newset([]string{a, b})
+		if st.validate() != nil {
+			return
+		}
+
+		rendered := st.rendered(sorted)
+		if !utf8.Valid(rendered) {
+			t.Fatalf("rendered invalid utf-8 %q", rendered)
+		}
+
+		lines := strings.Split(string(rendered), "\n")
+		if len(lines) != st.Len() {
+			t.Fatalf("rendered %d lines for %d selections: %q", len(lines), st.Len(), rendered)
+		}
+
+		for _, line := range lines {
+			out, err := st.processOutput([]byte(line+"\n"), nil)
+			if err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+			if out != strings.TrimSpace(a) && out != strings.TrimSpace(b) {
+				t.Fatalf("%q resolved to %q, which is neither %q nor %q", line, out, a, b)
+			}
+		}
+	})
+}
+
+func FuzzProcessOutput(f *testing.F) {
+	f.Add([]byte("one\n"), true)
+	f.Add([]byte(`one\ntwo`), false)
+	f.Add([]byte("\xff\n\n"), true)
+	f.Add([]byte(""), false)
+
+	st := newset([]string{"one", "one\ntwo", "tab\there"})
+	f.Fuzz(func(t *testing.T, output []byte, requireMatch bool) {
+		st.withRequireMatch(requireMatch)
+
+		out, idx, err := st.resolve(output, nil)
+		switch {
+		case err != nil:
+			if out != "" || idx != -1 {
+				t.Fatalf("error %v with result %q (%d)", err, out, idx)
+			}
+		case idx >= 0:
+			if out != []string{"one", "one\ntwo", "tab\there"}[idx] {
+				t.Fatalf("%q resolved to %q at %d", output, out, idx)
+			}
+		case requireMatch:
+			t.Fatalf("%q resolved to unknown %q", output, out)
+		case out == "":
+			t.Fatalf("%q resolved to an empty selection", output)
+		}
+	})
+}
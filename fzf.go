@@ -0,0 +1,169 @@
+package godmenu
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Fzf is a backend which runs the fzf fuzzy finder in the terminal.
+// The zero value is a usable backend; the fields enable fzf features
+// which dmenu does not have. Colors are not passed to fzf, so that it
+// uses the terminal's colors.
+//
+// Because the selections, query and key may all be relevant, use
+// Select rather than Do to retrieve them.
+type Fzf struct {
+	// Preview is a command that fzf runs to render a preview of
+	// the highlighted item, where "{}" is replaced by the item.
+	Preview string
+	// Expect lists keys (e.g. "ctrl-o") which accept the
+	// selection, in addition to enter. The key pressed is reported
+	// in the result.
+	Expect []string
+	// PrintQuery reports the query the user entered in the
+	// result. When nothing matches the query, the query is the
+	// selection, unless a match is required.
+	PrintQuery bool
+}
+
+// FzfResult is the outcome of an fzf menu.
+type FzfResult struct {
+	// Query is the text the user entered, when PrintQuery is set.
+	Query string
+	// Key is the key from Expect the user pressed, and is empty
+	// when the user pressed enter.
+	Key string
+	// Selections are the items the user chose, in order.
+	Selections []string
+	// Sources holds the name of the source of each selection
+	// (see Result.Source), which is empty for selections which
+	// did not come from the options' Sources.
+	Sources []string
+}
+
+func (f Fzf) launcher() *launcher {
+	return &launcher{name: "fzf", program: DefaultFzfPath, args: f.args, parse: f.parse, multi: true, terminal: true}
+}
+
+func (Fzf) Name() string                                    { return "fzf" }
+func (f Fzf) Validate(opts *Options) error                  { return f.launcher().Validate(opts) }
+func (f Fzf) Render(w io.Writer, s iter.Seq[string]) error  { return f.launcher().Render(w, s) }
+func (f Fzf) Parse(out []byte, err error) (Response, error) { return f.launcher().Parse(out, err) }
+
+func (f Fzf) Run(ctx context.Context, opts *Options, input io.Reader) ([]byte, error) {
+	return f.launcher().Run(ctx, opts, input)
+}
+
+// Select runs fzf with the options, returning the query, key, and all
+// selections the user made, which may be more than one when the
+// options' Multi is set. Each selection is processed, and recorded in
+// the options' History, as by Do.
+func (f Fzf) Select(ctx context.Context, opts Options) (*FzfResult, error) {
+	opts.Backend = f
+
+	if err := opts.loadSources(ctx); err != nil {
+		return nil, err
+	}
+
+	selections, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, _, err := invoke(ctx, &opts, selections.withRank(opts.History.scores()))
+	out, positions, err := selections.resolveLines(resp.Output, err)
+	if err != nil {
+		return nil, err
+	}
+	opts.History.record(out...)
+
+	res := &FzfResult{Query: resp.Query, Key: resp.Key, Selections: out, Sources: make([]string, len(out))}
+	for idx, pos := range positions {
+		res.Sources[idx] = opts.source(pos)
+	}
+	return res, nil
+}
+
+func (f Fzf) args(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 12)
+
+	if conf.CaseSensitive {
+		args = append(args, "+i")
+	} else {
+		args = append(args, "-i")
+	}
+
+	// fzf draws the prompt below the list by default; dmenu
+	// draws it at the top unless told otherwise.
+	if !conf.Bottom {
+		args = append(args, "--layout=reverse")
+	}
+
+	// the height includes the prompt and info lines.
+	if conf.Lines > 0 {
+		args = append(args, fmt.Sprintf("--height=%d", conf.Lines+2))
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "--prompt="+conf.Prompt)
+	}
+
+	if opts.Multi {
+		args = append(args, "--multi")
+	}
+
+	if f.Preview != "" {
+		args = append(args, "--preview="+f.Preview)
+	}
+
+	if len(f.Expect) > 0 {
+		args = append(args, "--expect="+strings.Join(f.Expect, ","))
+	}
+
+	if f.PrintQuery {
+		args = append(args, "--print-query")
+	}
+
+	return args
+}
+
+// parse splits fzf's output, which begins with the query (with
+// --print-query) and the key (with --expect), each on their own line,
+// followed by the selections. fzf exits with 1 when nothing matched
+// the query and 130 when the user dismissed the menu.
+func (f Fzf) parse(out []byte, err error) (Response, error) {
+	var resp Response
+
+	code, ok := exitCode(err)
+	switch {
+	case ok && code == 130:
+		return resp, canceled("fzf", err)
+	case err != nil && (!ok || code != 1):
+		return resp, launchError("fzf", err)
+	}
+
+	if f.PrintQuery {
+		line, rest, _ := bytes.Cut(out, []byte("\n"))
+		resp.Query, out = string(line), rest
+	}
+
+	if len(f.Expect) > 0 {
+		line, rest, _ := bytes.Cut(out, []byte("\n"))
+		resp.Key, out = string(line), rest
+	}
+
+	resp.Output = out
+
+	// when nothing matched, the query is the selection, as dmenu
+	// returns the text the user entered.
+	if ok && code == 1 && f.PrintQuery && len(bytes.TrimSpace(out)) == 0 {
+		resp.Output = []byte(resp.Query)
+	}
+
+	return resp, nil
+}
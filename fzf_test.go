@@ -0,0 +1,137 @@
+package godmenu
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestFzf(t *testing.T) {
+	t.Run("Arguments", func(t *testing.T) {
+		path, record := standIn(t, "two\n", 0)
+
+		out, err := Run(t.Context(), WithBackend(Fzf{}), DMenuPath(path), Items("one", "two"), MenuPrompt("pick> "), MenuLines(10))
+		if err != nil || out != "two" {
+			t.Fatal(out, err)
+		}
+
+		args, stdin := record()
+		expected := []string{"-i", "--layout=reverse", "--height=12", "--prompt=pick> "}
+		if !slices.Equal(args, expected) {
+			t.Errorf("got %q\nexpected %q", args, expected)
+		}
+		if stdin != "one\ntwo" {
+			t.Errorf("%q", stdin)
+		}
+	})
+	t.Run("Features", func(t *testing.T) {
+		fzf := Fzf{Preview: "cat {}", Expect: []string{"ctrl-o", "alt-enter"}, PrintQuery: true}
+		args := fzf.args(ResolveOptions(CaseSensitive(), MenuBottom(), MultiSelect()))
+		expected := []string{"+i", "--multi", "--preview=cat {}", "--expect=ctrl-o,alt-enter", "--print-query"}
+		if !slices.Equal(args, expected) {
+			t.Errorf("got %q\nexpected %q", args, expected)
+		}
+	})
+	t.Run("Terminal", func(t *testing.T) {
+		runner := &mockRunner{stdout: "two\n"}
+		out, err := Run(t.Context(), WithRunner(runner), WithBackend(Fzf{}), MenuLines(10), Items("one", "two"))
+		if err != nil || out != "two" {
+			t.Fatal(out, err)
+		}
+		if !slices.Equal(runner.cmd.Args, []string{"-i", "--layout=reverse", "--height=12"}) {
+			t.Errorf("%q", runner.cmd.Args)
+		}
+		// fzf draws its (--height) interface on standard error.
+		if runner.cmd.Stderr != os.Stderr {
+			t.Errorf("%+v", runner.cmd)
+		}
+
+		runner = &mockRunner{stdout: "two\n"}
+		if _, err := Run(t.Context(), WithRunner(runner), Items("one", "two")); err != nil {
+			t.Fatal(err)
+		}
+		if runner.cmd.Stderr != nil {
+			t.Error("dmenu's standard error should be captured")
+		}
+	})
+	t.Run("Select", func(t *testing.T) {
+		path, _ := standIn(t, "th\nctrl-o\nthree\nthirty\n", 0)
+
+		fzf := Fzf{Expect: []string{"ctrl-o"}, PrintQuery: true}
+		res, err := fzf.Select(t.Context(), *ResolveOptions(DMenuPath(path), Items("one", "three", "thirty"), RequireMatch(), MultiSelect()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Query != "th" || res.Key != "ctrl-o" {
+			t.Errorf("%+v", res)
+		}
+		if !slices.Equal(res.Selections, []string{"three", "thirty"}) {
+			t.Errorf("%q", res.Selections)
+		}
+	})
+	t.Run("SelectSources", func(t *testing.T) {
+		path, _ := standIn(t, "one\nf\n", 0)
+
+		res, err := Fzf{}.Select(t.Context(), *ResolveOptions(DMenuPath(path), Items("one"), WithSources(StaticSource("s", "e", "f")), RequireMatch(), MultiSelect()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(res.Selections, []string{"one", "f"}) || !slices.Equal(res.Sources, []string{"", "s"}) {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("SelectEnter", func(t *testing.T) {
+		path, _ := standIn(t, "\none\n", 0)
+
+		res, err := Fzf{Expect: []string{"ctrl-o"}}.Select(t.Context(), *ResolveOptions(DMenuPath(path), Items("one", "two")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Key != "" || res.Query != "" || !slices.Equal(res.Selections, []string{"one"}) {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("SelectUnknown", func(t *testing.T) {
+		path, _ := standIn(t, "one\nfour\n", 0)
+
+		res, err := Fzf{}.Select(t.Context(), *ResolveOptions(DMenuPath(path), Items("one", "two"), RequireMatch(), MultiSelect()))
+		if !errors.Is(err, ErrSelectionUnknown) || res != nil {
+			t.Error(res, err)
+		}
+	})
+	t.Run("NoMatch", func(t *testing.T) {
+		path, _ := standIn(t, "xyz\n", 1)
+
+		// the query is the free-text selection, as with dmenu.
+		res, err := Fzf{PrintQuery: true}.Select(t.Context(), *ResolveOptions(DMenuPath(path), Items("one", "two")))
+		if err != nil || res.Query != "xyz" || !slices.Equal(res.Selections, []string{"xyz"}) {
+			t.Error(res, err)
+		}
+
+		result, err := RunResult(t.Context(), WithBackend(Fzf{PrintQuery: true}), DMenuPath(path), Items("one", "two"))
+		if err != nil || result.Value != "xyz" || result.Query != "xyz" || result.Matched {
+			t.Error(result, err)
+		}
+
+		res, err = Fzf{PrintQuery: true}.Select(t.Context(), *ResolveOptions(DMenuPath(path), Items("one", "two"), RequireMatch()))
+		if !errors.Is(err, ErrSelectionUnknown) || res != nil {
+			t.Error(res, err)
+		}
+
+		// without --print-query, fzf prints nothing.
+		path, _ = standIn(t, "", 1)
+		res, err = Fzf{}.Select(t.Context(), *ResolveOptions(DMenuPath(path), Items("one", "two")))
+		if !errors.Is(err, ErrSelectionMissing) || res != nil {
+			t.Error(res, err)
+		}
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		path, _ := standIn(t, "", 130)
+
+		out, err := Run(t.Context(), WithBackend(Fzf{}), DMenuPath(path), Items("one", "two"))
+		if !errors.Is(err, ErrSelectionMissing) || out != "" {
+			t.Error(out, err)
+		}
+	})
+}
@@ -3,11 +3,14 @@
 package godmenu
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"os/exec"
+	"io"
+	"sync"
+	"time"
 )
 
 var (
@@ -17,90 +20,354 @@ var (
 	ErrConfigurationInvalid = errors.New("invalid configuration")
 	ErrSelectionRejected    = errors.New("selection rejected")
 	ErrConfirmation         = errors.New("selection confirmation")
+	// ErrCanceled is returned when the user dismissed the menu
+	// (e.g. by pressing escape) without making a selection.
+	// Because there is no selection, these are also
+	// ErrSelectionMissing errors.
+	ErrCanceled = errors.New("menu canceled")
+	// ErrTimeout is returned when the context's deadline passed
+	// before the user made a selection. These errors also wrap
+	// context.DeadlineExceeded, and, like menus whose context was
+	// canceled, are not ErrDmenuFailure errors.
+	ErrTimeout = errors.New("menu timed out")
+	// ErrReplayMismatch is returned by a Replay runner when a
+	// launcher is not called as it was in the recording.
+	ErrReplayMismatch = errors.New("replay mismatch")
 )
 
 // Do shells out to dmenu with the given options and returns the
 // selected result. If there was a problem with the command, the error
 // is returned.
 func Do(ctx context.Context, opts Options) (string, error) {
-	selections, err := opts.validate()
+	res, err := DoResult(ctx, opts)
 	if err != nil {
 		return "", err
 	}
+	return res.Value, nil
+}
 
-	cmd := exec.CommandContext(ctx, opts.Flags.Path, opts.Flags.args()...)
+// DoResult runs the menu, as Do, and describes the outcome in a
+// Result. When the backend ran but the menu failed, the result
+// (reporting the backend, exit code and duration) is returned with
+// the error; otherwise errors are returned with a nil result. When
+// the options have a History, the selection is recorded in it.
+func DoResult(ctx context.Context, opts Options) (*Result, error) {
+	res, err := doResult(ctx, opts)
+	if err != nil {
+		return res, err
+	}
+	opts.History.record(res.Value)
+	return res, nil
+}
+
+func doResult(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Multi {
+		return nil, fmt.Errorf("%w: multiple selections require DoMulti", ErrConfigurationInvalid)
+	}
+
+	if err := opts.loadSources(ctx); err != nil {
+		return nil, err
+	}
 
-	cmd.Stdin = bytes.NewBuffer(selections.rendered(opts.Sorted))
+	selections, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
 
-	out, err := selections.processOutput(cmd.CombinedOutput())
+	resp, res, err := invoke(ctx, &opts, selections.withRank(opts.History.scores()))
+	res.Value, res.Index, err = selections.resolve(resp.Output, err)
 	if err != nil {
-		return "", err
+		return res, err
 	}
 
-	if opts.ConfirmSubstitution && !selections.check(out) {
+	res.Matched = res.Index >= 0
+	res.Source = opts.source(res.Index)
+	res.Key = resp.Key
+	res.Query = resp.Query
+	if res.Query == "" && !res.Matched {
+		res.Query = string(bytes.TrimSpace(resp.Output))
+	}
+
+	if opts.ConfirmSubstitution && !selections.matches(res.Value) {
+		// the confirmation menu offers the substitution itself,
+		// which the user may still edit, and is not transformed.
 		confirm := opts
+		confirm.Selections = []string{res.Value, "accept", "reject"}
+		confirm.Items = nil
+		confirm.Sources = nil
+		confirm.Stream = nil
+		confirm.History = nil
+		confirm.Transform = nil
+		confirm.ConfirmSubstitution = false
 
-		confirm.with(Items(out, "accept", "reject"))
+		out := res.Value
+		confirmRes, err := DoResult(ctx, confirm)
+		if confirmRes != nil {
+			res.Duration += confirmRes.Duration
+		}
 
-		confirmOut, err := Do(ctx, confirm)
 		switch {
 		case err != nil:
-			return "", fmt.Errorf("%w: during %w", err, ErrConfirmation)
-		case confirmOut == out:
-			return out, nil
-		case confirmOut == "accept":
-			return out, nil
-		case confirmOut == "reject":
-			return "", fmt.Errorf("during %w, %q was rejected: %w", ErrConfirmation, out, ErrSelectionRejected)
+			return res, fmt.Errorf("%w: during %w", err, ErrConfirmation)
+		case confirmRes.Value == out:
+			return res, nil
+		case confirmRes.Value == "accept":
+			return res, nil
+		case confirmRes.Value == "reject":
+			return res, fmt.Errorf("during %w, %q was rejected: %w", ErrConfirmation, out, ErrSelectionRejected)
 		case opts.RequireMatch:
-			return out, fmt.Errorf("modified original selection %q, but selections must be an exact match (%q): %w during  %w", out, confirmOut, ErrSelectionUnknown, ErrConfirmation)
-		case confirmOut == "":
-			return "", fmt.Errorf("during %w: %w", ErrConfirmation, ErrSelectionMissing)
+			return res, fmt.Errorf("modified original selection %q, but selections must be an exact match (%q): %w during  %w", out, confirmRes.Value, ErrSelectionUnknown, ErrConfirmation)
+		case confirmRes.Value == "":
+			return res, fmt.Errorf("during %w: %w", ErrConfirmation, ErrSelectionMissing)
 		default:
-			return confirmOut, nil
+			res.Value = confirmRes.Value
+			return res, nil
 		}
 	}
 
+	return res, nil
+}
+
+// DoMulti runs the menu, as Do, allowing the user to choose more than
+// one selection. The selections are returned in the order that the
+// backend reported them. Backends which cannot return more than one
+// selection fail validation; dmenu requires the multi-select patch,
+// which selects with ctrl-enter.
+func DoMulti(ctx context.Context, opts Options) ([]string, error) {
+	opts.Multi = true
+
+	if err := opts.loadSources(ctx); err != nil {
+		return nil, err
+	}
+
+	selections, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, _, err := invoke(ctx, &opts, selections.withRank(opts.History.scores()))
+	out, _, err := selections.resolveLines(resp.Output, err)
+	if err != nil {
+		return nil, err
+	}
+	opts.History.record(out...)
 	return out, nil
 }
 
+// invoke renders the selections for the backend, runs it, and parses
+// its response. The result describes the backend's invocation.
+func invoke(ctx context.Context, opts *Options, selections *set) (Response, *Result, error) {
+	backend := opts.backend()
+	res := &Result{Index: -1, Backend: backend.Name()}
+
+	ib, ok := backend.(indexedBackend)
+	raw := ok && ib.indexed()
+	order := selections.order(opts.Sorted)
+
+	runCtx, input, finish := render(ctx, opts, backend, selections, order, raw)
+
+	start := time.Now()
+	out, err := backend.Run(runCtx, opts, input)
+	res.Duration = time.Since(start)
+	res.ExitCode, _ = exitCode(err)
+
+	if rerr := finish(); rerr != nil {
+		return Response{}, res, rerr
+	}
+
+	resp, err := backend.Parse(out, err)
+	if resp.Indexes != nil {
+		resp.Output = selections.indexed(order, resp)
+	}
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		err = interrupt(err)
+		switch {
+		case errors.Is(ctxErr, context.DeadlineExceeded):
+			err = fmt.Errorf("%s: %w: %w: %w", backend.Name(), ErrTimeout, ctxErr, err)
+		case !errors.Is(err, ctxErr):
+			err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
+	}
+
+	return resp, res, err
+}
+
+// renderBuffers holds the buffers that render writes through, which
+// are reused between menus.
+var renderBuffers = sync.Pool{New: func() any { return bufio.NewWriterSize(nil, 64*1024) }}
+
+// render writes the selections, in the order, followed by the
+// options' stream, into a pipe which the backend reads while it
+// runs, so that the rendered selections are never held in memory.
+// Streamed selections are added to the set as they arrive, and an
+// error from the stream cancels the returned context, which ends the
+// menu. The finish function stops rendering, after which the set is
+// no longer modified, and reports any error from the stream or from
+// the backend's Render.
+func render(ctx context.Context, opts *Options, backend Backend, selections *set, order []int, raw bool) (context.Context, io.Reader, func() error) {
+	ctx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+
+	var mu sync.Mutex
+	var stopped bool
+	var streamErr, renderErr error
+
+	buf := renderBuffers.Get().(*bufio.Writer)
+	buf.Reset(pw)
+
+	pos := len(opts.Selections) + len(opts.Items)
+	all := func(yield func(string) bool) {
+		for sel := range selections.present(order, raw) {
+			if !yield(sel) {
+				return
+			}
+		}
+		if opts.Stream == nil {
+			return
+		}
+
+		// the stream may pause at any point, so the launcher
+		// receives each streamed selection as it is rendered.
+		if buf.Flush() != nil {
+			return
+		}
+		for sel, err := range opts.Stream {
+			if err == nil {
+				err = ctx.Err()
+			}
+
+			mu.Lock()
+			if stopped {
+				mu.Unlock()
+				return
+			}
+			if err != nil {
+				streamErr = err
+				mu.Unlock()
+				cancel()
+				return
+			}
+			shown, label, ok := selections.stream(sel, pos)
+			mu.Unlock()
+
+			pos++
+			if !ok {
+				continue
+			}
+			if raw {
+				shown = label
+			}
+			if !yield(shown) || buf.Flush() != nil {
+				return
+			}
+		}
+	}
+
+	go func() {
+		defer close(done)
+
+		err := backend.Render(buf, all)
+		if err == nil {
+			err = buf.Flush()
+		}
+		buf.Reset(nil)
+		renderBuffers.Put(buf)
+
+		// once finish closes the pipe, errors writing to it
+		// are expected.
+		mu.Lock()
+		if !stopped {
+			renderErr = err
+		}
+		mu.Unlock()
+		_ = pw.CloseWithError(err)
+	}()
+
+	return ctx, pr, func() error {
+		mu.Lock()
+		cancel()
+		stopped = true
+		_ = pr.Close()
+		mu.Unlock()
+
+		// the stream may block indefinitely (e.g. on a channel),
+		// and is not waited for; the selections alone always end
+		// once the pipe is closed.
+		if opts.Stream == nil {
+			<-done
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case streamErr != nil:
+			return fmt.Errorf("streaming selections for %s: %w", backend.Name(), streamErr)
+		case renderErr != nil:
+			return fmt.Errorf("rendering selections for %s: %w", backend.Name(), renderErr)
+		default:
+			return nil
+		}
+	}
+}
+
 // Run calls Do but takes its configuration as Args arguments.
 func Run(ctx context.Context, args ...Arg) (string, error) { return Do(ctx, newop().apply(args).ref()) }
-func MakeOptions(s ...string) *Options                     { return newop().extendSelections(s).flags() }
-func ResolveOptions(arg ...Arg) *Options                   { return newop().apply(arg) }
-func DefaultFlags() *Flags                                 { c := defaultDmenuConfig; return &c }
-func WithFlags(n *Flags) Arg                               { return func(o *Options) { o.Flags = n } }
-func WithOptions(override *Options) Arg                    { return func(o *Options) { *o = *override } }
-func WithSelections(s ...string) Arg                       { return ExtendSelections(s) }
-func Items(s ...string) Arg                                { return ExtendSelections(s) }
-func SetMatchRequirement(state bool) Arg                   { return func(o *Options) { o.RequireMatch = state } }
-func RequireMatch() Arg                                    { return SetMatchRequirement(true) }
-func AllowMatch() Arg                                      { return SetMatchRequirement(false) }
-func SetConfirmSubstituion(state bool) Arg                 { return func(o *Options) { o.ConfirmSubstitution = state } }
-func ConfirmSubstituion() Arg                              { return SetConfirmSubstituion(true) }
-func SkipConfirmSubstitution() Arg                         { return SetConfirmSubstituion(false) }
-func SetUniqueSelectionPolicy(state bool) Arg              { return func(o *Options) { o.AllowDuplicates = state } }
-func AllowDuplicateSelections() Arg                        { return SetUniqueSelectionPolicy(true) }
-func RequireUniqueSelections() Arg                         { return SetUniqueSelectionPolicy(false) }
-func Selections(s ...string) Arg                           { return ExtendSelections(s) }
-func Prompt(p string) Arg                                  { return MenuPrompt(p) }
-func Sorted() Arg                                          { return func(o *Options) { o.Sorted = true } }
-func ExtendSelections(s []string) Arg                      { return func(o *Options) { o.extendSelections(s) } }
-func SetSelections(s []string) Arg                         { return func(o *Options) { o.Selections = s } }
-func ResetSelections() Arg                                 { return func(o *Options) { o.Selections = []string{} } }
-func Unsorted() Arg                                        { return func(o *Options) { o.Sorted = false } }
-func TextColor(c string) Arg                               { return func(o *Options) { o.Flags.TextColor = c } }
-func BackgroundColor(c string) Arg                         { return func(o *Options) { o.Flags.BackgroundColor = c } }
-func SelectedText(c string) Arg                            { return func(o *Options) { o.Flags.SelectedTextColor = c } }
-func SelectedBgColor(c string) Arg                         { return func(o *Options) { o.Flags.SelectedBgColor = c } }
-func CaseSensitive() Arg                                   { return func(o *Options) { o.Flags.CaseSensitive = true } }
-func CaseInsensitive() Arg                                 { return func(o *Options) { o.Flags.CaseSensitive = false } }
-func DMenuPath(p string) Arg                               { return func(o *Options) { o.Flags.Path = p } }
-func MenuPrompt(p string) Arg                              { return func(o *Options) { o.Flags.Prompt = p } }
-func MenuBottom() Arg                                      { return func(o *Options) { o.Flags.Bottom = true } }
-func MenuTop() Arg                                         { return func(o *Options) { o.Flags.Bottom = false } }
-func MenuLines(n int) Arg                                  { return func(o *Options) { o.Flags.Lines = n } }
-func MenuMonitor(n int) Arg                                { return func(o *Options) { o.Flags.Monitor = n } }
-func MenuMonitorUnset() Arg                                { return func(o *Options) { o.Flags.Monitor = -1 } }
-func MenuWindowID(n int) Arg                               { return func(o *Options) { o.Flags.WindowID = n } }
-func MenuWindowIDUnset() Arg                               { return func(o *Options) { o.Flags.WindowID = -1 } }
+func RunResult(ctx context.Context, args ...Arg) (*Result, error) {
+	return DoResult(ctx, newop().apply(args).ref())
+}
+func RunMulti(ctx context.Context, args ...Arg) ([]string, error) {
+	return DoMulti(ctx, newop().apply(args).ref())
+}
+func SetSelectionPolicy(p SelectionPolicy) Arg  { return func(o *Options) { o.Policy = p } }
+func WithSelectionPolicy(p SelectionPolicy) Arg { return func(o *Options) { o.Policy |= p } }
+func WithStderrHook(fn func(backend, stderr string)) Arg {
+	return func(o *Options) { o.StderrHook = fn }
+}
+func MakeOptions(s ...string) *Options        { return newop().extendSelections(s).flags() }
+func ResolveOptions(arg ...Arg) *Options      { return newop().apply(arg) }
+func DefaultFlags() *Flags                    { c := defaultDmenuConfig; return &c }
+func WithFlags(n *Flags) Arg                  { return func(o *Options) { o.Flags = n.clone() } }
+func WithBackend(b Backend) Arg               { return func(o *Options) { o.Backend = b } }
+func WithOptions(override *Options) Arg       { return func(o *Options) { *o = override.clone() } }
+func WithRunner(r Runner) Arg                 { return func(o *Options) { o.Runner = r } }
+func WithEnv(env ...string) Arg               { return func(o *Options) { o.Env = extend(o.Env, env...) } }
+func WorkingDir(dir string) Arg               { return func(o *Options) { o.Dir = dir } }
+func WithSelections(s ...string) Arg          { return ExtendSelections(s) }
+func Items(s ...string) Arg                   { return ExtendSelections(s) }
+func SetMatchRequirement(state bool) Arg      { return func(o *Options) { o.RequireMatch = state } }
+func RequireMatch() Arg                       { return SetMatchRequirement(true) }
+func AllowMatch() Arg                         { return SetMatchRequirement(false) }
+func SetConfirmSubstituion(state bool) Arg    { return func(o *Options) { o.ConfirmSubstitution = state } }
+func ConfirmSubstituion() Arg                 { return SetConfirmSubstituion(true) }
+func SkipConfirmSubstitution() Arg            { return SetConfirmSubstituion(false) }
+func SetMultiSelection(state bool) Arg        { return func(o *Options) { o.Multi = state } }
+func MultiSelect() Arg                        { return SetMultiSelection(true) }
+func SingleSelect() Arg                       { return SetMultiSelection(false) }
+func SetUniqueSelectionPolicy(state bool) Arg { return func(o *Options) { o.AllowDuplicates = state } }
+func AllowDuplicateSelections() Arg           { return SetUniqueSelectionPolicy(true) }
+func RequireUniqueSelections() Arg            { return SetUniqueSelectionPolicy(false) }
+func WithItems(items ...Item) Arg             { return func(o *Options) { o.Items = extend(o.Items, items...) } }
+func SetItems(items []Item) Arg               { return func(o *Options) { o.Items = items } }
+func Selections(s ...string) Arg              { return ExtendSelections(s) }
+func Prompt(p string) Arg                     { return MenuPrompt(p) }
+func Sorted() Arg                             { return func(o *Options) { o.Sorted = true } }
+func ExtendSelections(s []string) Arg         { return func(o *Options) { o.extendSelections(s) } }
+func SetSelections(s []string) Arg            { return func(o *Options) { o.Selections = s } }
+func ResetSelections() Arg                    { return func(o *Options) { o.Selections = []string{} } }
+func Unsorted() Arg                           { return func(o *Options) { o.Sorted = false } }
+func TextColor(c string) Arg                  { return func(o *Options) { o.Flags.TextColor = c } }
+func BackgroundColor(c string) Arg            { return func(o *Options) { o.Flags.BackgroundColor = c } }
+func SelectedText(c string) Arg               { return func(o *Options) { o.Flags.SelectedTextColor = c } }
+func SelectedBgColor(c string) Arg            { return func(o *Options) { o.Flags.SelectedBgColor = c } }
+func CaseSensitive() Arg                      { return func(o *Options) { o.Flags.CaseSensitive = true } }
+func CaseInsensitive() Arg                    { return func(o *Options) { o.Flags.CaseSensitive = false } }
+func DMenuPath(p string) Arg                  { return func(o *Options) { o.Flags.Path = p } }
+func MenuPrompt(p string) Arg                 { return func(o *Options) { o.Flags.Prompt = p } }
+func MenuBottom() Arg                         { return func(o *Options) { o.Flags.Bottom = true } }
+func MenuTop() Arg                            { return func(o *Options) { o.Flags.Bottom = false } }
+func MenuLines(n int) Arg                     { return func(o *Options) { o.Flags.Lines = n } }
+func MenuMonitor(n int) Arg                   { return func(o *Options) { o.Flags.Monitor = &n } }
+func MenuMonitorUnset() Arg                   { return func(o *Options) { o.Flags.Monitor = nil } }
+func MenuWindowID(n int) Arg                  { return func(o *Options) { o.Flags.WindowID = &n } }
+func MenuWindowIDUnset() Arg                  { return func(o *Options) { o.Flags.WindowID = nil } }
@@ -3,11 +3,25 @@ package godmenu
 import (
 	"context"
 	"errors"
+	"io"
+	"iter"
 	"os/exec"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
 
+// brokenRender is a backend which fails to render its selections.
+type brokenRender struct{ mockBackend }
+
+func (*brokenRender) Render(w io.Writer, selections iter.Seq[string]) error {
+	if _, err := io.WriteString(w, "partial"); err != nil {
+		return err
+	}
+	return errors.New("no template")
+}
+
 func TestDmenu(t *testing.T) {
 	t.Run("Set", func(t *testing.T) {
 		t.Run("CheckNilSafe", func(t *testing.T) {
@@ -89,6 +103,35 @@ func TestDmenu(t *testing.T) {
 				}
 			})
 		})
+		t.Run("Pipe", func(t *testing.T) {
+			selections := make([]string, 100_000)
+			for idx := range selections {
+				selections[idx] = strconv.Itoa(idx)
+			}
+
+			t.Run("Large", func(t *testing.T) {
+				runner := &mockRunner{stdout: "99999\n"}
+				res, err := RunResult(t.Context(), WithRunner(runner), SetSelections(selections), Sorted(), RequireMatch())
+				if err != nil || res.Value != "99999" || res.Index != 99999 {
+					t.Fatal(res, err)
+				}
+				if !strings.HasPrefix(runner.stdin, "0\n1\n10\n100\n1000\n10000\n10001\n") || strings.Count(runner.stdin, "\n") != len(selections)-1 {
+					t.Errorf("%.40q", runner.stdin)
+				}
+			})
+			t.Run("Unread", func(t *testing.T) {
+				out, err := Run(t.Context(), WithRunner(&mockRunner{stdout: "7", unread: true}), SetSelections(selections))
+				if err != nil || out != "7" {
+					t.Error(out, err)
+				}
+			})
+			t.Run("RenderError", func(t *testing.T) {
+				_, err := Run(t.Context(), WithBackend(&brokenRender{}), SetSelections(selections))
+				if err == nil || !strings.Contains(err.Error(), "rendering selections for mock: no template") {
+					t.Error(err)
+				}
+			})
+		})
 	})
 	t.Run("ProcessOutput", func(t *testing.T) {
 		t.Run("PermissiveMode", func(t *testing.T) {
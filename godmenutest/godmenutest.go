@@ -0,0 +1,288 @@
+// Package godmenutest provides a scriptable stand-in for dmenu, and
+// the other launchers, so that code which calls godmenu can be tested
+// without a display or a user.
+//
+// The fake launcher is a small shell script, which godmenu runs
+// exactly as it runs the real program: configure it with the
+// launcher's Arg (or DMenuPath and Path), queue the responses that
+// the "user" gives, and then make assertions about what the menu
+// presented:
+//
+//	fake := godmenutest.New(t).Select("two")
+//	out, err := godmenu.Run(ctx, fake.Arg(), godmenu.Items("one", "two"))
+//	fake.AssertPresented("one", "two")
+//
+// Alternatively, the launcher's Runner runs the fake in place of
+// every launcher program, for any backend, without changing the flags.
+//
+// The fake requires a posix shell, and skips the test when there is
+// none. Each call consumes the next queued response, in order; calls
+// without a queued response fail. The fake is not safe for
+// concurrent calls.
+package godmenutest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tychoish/godmenu"
+)
+
+// NoResponseExitCode is the exit code of the fake launcher when it
+// is called without a queued response.
+const NoResponseExitCode = 99
+
+// Response describes how the fake launcher responds to one call.
+type Response struct {
+	// Output is written to standard output verbatim.
+	Output string
+	// Stderr is written to standard error.
+	Stderr string
+	// ExitCode is the launcher's exit status.
+	ExitCode int
+	// Delay is how long the launcher waits, after reading its
+	// input, before it responds, as a user would.
+	Delay time.Duration
+}
+
+// Call records one invocation of the fake launcher.
+type Call struct {
+	// Args holds the launcher's arguments, not including the
+	// name of the program.
+	Args []string
+	// Stdin holds the launcher's input, as rendered by the backend.
+	Stdin string
+}
+
+// Presented returns the selections that the menu presented: the
+// lines of the launcher's input.
+func (c Call) Presented() []string {
+	if c.Stdin == "" {
+		return nil
+	}
+	return strings.Split(c.Stdin, "\n")
+}
+
+// Launcher is a fake launcher. Construct launchers with New.
+type Launcher struct {
+	t      testing.TB
+	dir    string
+	path   string
+	queued int
+}
+
+// New writes a fake launcher into a temporary directory, which is
+// removed at the end of the test.
+func New(t testing.TB) *Launcher {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("the fake launcher requires a posix shell")
+	}
+
+	l := &Launcher{t: t, dir: t.TempDir()}
+	l.path = filepath.Join(l.dir, "launcher")
+
+	if err := os.WriteFile(l.path, []byte(fmt.Sprintf(script, l.dir, NoResponseExitCode)), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
+
+// script records the arguments and input of each call, and then
+// plays back the queued response for the call. The delay does not
+// hold the output open, so that killing the launcher (for instance,
+// when the context is canceled) ends the call promptly.
+const script = `#!/bin/sh
+dir=%q
+n=$(cat "$dir/calls" 2>/dev/null || echo 0)
+echo $((n + 1)) > "$dir/calls"
+for arg in "$@"; do printf '%%s\0' "$arg"; done > "$dir/$n.args"
+cat > "$dir/$n.stdin"
+r="$dir/response.$n"
+if [ ! -e "$r.code" ]; then
+	echo "godmenutest: no response queued for call $n" >&2
+	exit %d
+fi
+if [ -e "$r.delay" ]; then
+	sleep "$(cat "$r.delay")" < /dev/null > /dev/null 2>&1
+fi
+cat "$r.stderr" >&2
+cat "$r.stdout"
+exit "$(cat "$r.code")"
+`
+
+// Path returns the path of the fake launcher.
+func (l *Launcher) Path() string { return l.path }
+
+// Arg configures a menu to run the fake launcher.
+func (l *Launcher) Arg() godmenu.Arg { return godmenu.DMenuPath(l.path) }
+
+// Runner returns a runner, for godmenu.WithRunner, which runs the
+// fake launcher in place of any launcher program.
+func (l *Launcher) Runner() godmenu.Runner { return runner{path: l.path} }
+
+type runner struct{ path string }
+
+func (r runner) LookPath(string) (string, error) { return r.path, nil }
+
+func (r runner) Run(ctx context.Context, cmd godmenu.Command) ([]byte, []byte, error) {
+	cmd.Program = r.path
+	return godmenu.ExecRunner{}.Run(ctx, cmd)
+}
+
+// Respond queues a response.
+func (l *Launcher) Respond(r Response) *Launcher {
+	l.t.Helper()
+
+	prefix := filepath.Join(l.dir, fmt.Sprintf("response.%d", l.queued))
+	files := map[string]string{
+		".stdout": r.Output,
+		".stderr": r.Stderr,
+		".code":   strconv.Itoa(r.ExitCode),
+	}
+	if r.Delay > 0 {
+		files[".delay"] = strconv.FormatFloat(r.Delay.Seconds(), 'f', 3, 64)
+	}
+
+	// the exit code is written last: the launcher treats the
+	// response as queued once it exists.
+	for _, ext := range []string{".stdout", ".stderr", ".delay", ".code"} {
+		content, ok := files[ext]
+		if !ok {
+			continue
+		}
+		if err := os.WriteFile(prefix+ext, []byte(content), 0o600); err != nil {
+			l.t.Fatal(err)
+		}
+	}
+
+	l.queued++
+	return l
+}
+
+// Select queues a response in which the user chooses (or enters) the
+// selection.
+func (l *Launcher) Select(selection string) *Launcher {
+	return l.Respond(Response{Output: selection + "\n"})
+}
+
+// SelectMany queues a response in which the user chooses several
+// selections, as from a multi-selection menu.
+func (l *Launcher) SelectMany(selections ...string) *Launcher {
+	return l.Respond(Response{Output: strings.Join(selections, "\n") + "\n"})
+}
+
+// Cancel queues a response in which the user dismisses the menu.
+func (l *Launcher) Cancel() *Launcher { return l.Respond(Response{ExitCode: 1}) }
+
+// Fail queues a response in which the launcher fails with the exit
+// code and message.
+func (l *Launcher) Fail(code int, stderr string) *Launcher {
+	return l.Respond(Response{ExitCode: code, Stderr: stderr})
+}
+
+// Calls returns the calls of the fake launcher, in order.
+func (l *Launcher) Calls() []Call {
+	l.t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(l.dir, "calls"))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		l.t.Fatal(err)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		l.t.Fatal(err)
+	}
+
+	calls := make([]Call, 0, count)
+	for idx := range count {
+		args, err := os.ReadFile(filepath.Join(l.dir, fmt.Sprintf("%d.args", idx)))
+		if err != nil {
+			l.t.Fatal(err)
+		}
+		stdin, err := os.ReadFile(filepath.Join(l.dir, fmt.Sprintf("%d.stdin", idx)))
+		if err != nil {
+			l.t.Fatal(err)
+		}
+
+		call := Call{Stdin: string(stdin)}
+		if len(args) > 0 {
+			call.Args = strings.Split(strings.TrimSuffix(string(args), "\x00"), "\x00")
+		}
+		calls = append(calls, call)
+	}
+
+	return calls
+}
+
+// Last returns the most recent call, and fails the test when the
+// launcher was not called.
+func (l *Launcher) Last() Call {
+	l.t.Helper()
+
+	calls := l.Calls()
+	if len(calls) == 0 {
+		l.t.Fatal("godmenutest: the launcher was not called")
+	}
+	return calls[len(calls)-1]
+}
+
+// AssertCalls checks the number of calls.
+func (l *Launcher) AssertCalls(n int) {
+	l.t.Helper()
+	if calls := l.Calls(); len(calls) != n {
+		l.t.Errorf("launcher called %d times, expected %d", len(calls), n)
+	}
+}
+
+// AssertDone checks that every queued response was used.
+func (l *Launcher) AssertDone() {
+	l.t.Helper()
+	if calls := len(l.Calls()); calls < l.queued {
+		l.t.Errorf("launcher called %d times, but %d responses were queued", calls, l.queued)
+	}
+}
+
+// AssertPresented checks the selections that the most recent call
+// presented, in order.
+func (l *Launcher) AssertPresented(selections ...string) {
+	l.t.Helper()
+	if got := l.Last().Presented(); !slices.Equal(got, selections) {
+		l.t.Errorf("menu presented %q, expected %q", got, selections)
+	}
+}
+
+// AssertArgs checks all of the arguments of the most recent call.
+func (l *Launcher) AssertArgs(args ...string) {
+	l.t.Helper()
+	if got := l.Last().Args; !slices.Equal(got, args) {
+		l.t.Errorf("launcher called with %q, expected %q", got, args)
+	}
+}
+
+// AssertFlag checks that the most recent call passed the flag,
+// followed by the values, if any.
+func (l *Launcher) AssertFlag(flag string, values ...string) {
+	l.t.Helper()
+
+	want := append([]string{flag}, values...)
+	args := l.Last().Args
+	for idx := range args {
+		if idx+len(want) <= len(args) && slices.Equal(args[idx:idx+len(want)], want) {
+			return
+		}
+	}
+	l.t.Errorf("launcher called with %q, expected %q", args, want)
+}
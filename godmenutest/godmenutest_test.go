@@ -0,0 +1,137 @@
+package godmenutest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tychoish/godmenu"
+)
+
+// recorder captures assertion failures, rather than failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestLauncher(t *testing.T) {
+	t.Run("Working", func(t *testing.T) {
+		fake := New(t).Select("b")
+
+		out, err := godmenu.Run(t.Context(), fake.Arg(), godmenu.Items("a", "b", "c"), godmenu.MenuPrompt("pick:"))
+		if err != nil || out != "b" {
+			t.Fatal(out, err)
+		}
+
+		fake.AssertCalls(1)
+		fake.AssertDone()
+		fake.AssertPresented("a", "b", "c")
+		fake.AssertFlag("-p", "pick:")
+		fake.AssertFlag("-i")
+	})
+	t.Run("Runner", func(t *testing.T) {
+		fake := New(t).Select("two")
+
+		out, err := godmenu.Run(t.Context(), godmenu.WithRunner(fake.Runner()), godmenu.WithBackend(godmenu.Fuzzel()), godmenu.Items("one", "two"))
+		if err != nil || out != "two" {
+			t.Fatal(out, err)
+		}
+		fake.AssertFlag("--dmenu")
+		fake.AssertPresented("one", "two")
+	})
+	t.Run("Queue", func(t *testing.T) {
+		fake := New(t).Select("one").Cancel().Fail(2, "cannot open display")
+
+		out, err := godmenu.Run(t.Context(), fake.Arg(), godmenu.Items("one", "two"))
+		if err != nil || out != "one" {
+			t.Fatal(out, err)
+		}
+
+		_, err = godmenu.Run(t.Context(), fake.Arg(), godmenu.Items("three"))
+		if !errors.Is(err, godmenu.ErrCanceled) {
+			t.Error(err)
+		}
+
+		_, err = godmenu.Run(t.Context(), fake.Arg(), godmenu.Items("four"))
+		var derr *godmenu.DmenuError
+		if !errors.As(err, &derr) || derr.ExitCode != 2 || derr.Stderr != "cannot open display" {
+			t.Error(err)
+		}
+
+		calls := fake.Calls()
+		if len(calls) != 3 {
+			t.Fatal(calls)
+		}
+		if !slices.Equal(calls[0].Presented(), []string{"one", "two"}) || calls[2].Stdin != "four" {
+			t.Errorf("%+v", calls)
+		}
+		fake.AssertDone()
+	})
+	t.Run("Exhausted", func(t *testing.T) {
+		fake := New(t)
+
+		_, err := godmenu.Run(t.Context(), fake.Arg(), godmenu.Items("one"))
+		var derr *godmenu.DmenuError
+		if !errors.As(err, &derr) || derr.ExitCode != NoResponseExitCode {
+			t.Error(err)
+		}
+		fake.AssertCalls(1)
+	})
+	t.Run("Multi", func(t *testing.T) {
+		fake := New(t).SelectMany("one", "three")
+
+		out, err := godmenu.RunMulti(t.Context(), fake.Arg(), godmenu.WithBackend(godmenu.Rofi()), godmenu.Items("one", "two", "three"))
+		if err != nil || !slices.Equal(out, []string{"one", "three"}) {
+			t.Fatal(out, err)
+		}
+		fake.AssertFlag("-multi-select")
+	})
+	t.Run("Delay", func(t *testing.T) {
+		fake := New(t).Respond(Response{Output: "one\n", Delay: time.Minute})
+
+		ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+		t.Cleanup(cancel)
+
+		start := time.Now()
+		_, err := godmenu.Run(ctx, fake.Arg(), godmenu.Items("one"))
+		if !errors.Is(err, godmenu.ErrTimeout) {
+			t.Error(err)
+		}
+		if dur := time.Since(start); dur > 10*time.Second {
+			t.Error("timed out launcher was not stopped promptly", dur)
+		}
+	})
+	t.Run("Arguments", func(t *testing.T) {
+		fake := New(t).Select("one")
+
+		if _, err := godmenu.Run(t.Context(), fake.Arg(), godmenu.Items("one"), godmenu.MenuPrompt("two words")); err != nil {
+			t.Fatal(err)
+		}
+		fake.AssertArgs("-i", "-fn", "Source Code Pro-13", "-p", "two words",
+			"-nb", "#000000", "-sb", "#005577", "-nf", "#ffffff", "-sf", "#ffffff")
+	})
+	t.Run("Assertions", func(t *testing.T) {
+		rec := &recorder{TB: t}
+		fake := New(rec).Select("one").Select("two")
+
+		if _, err := godmenu.Run(t.Context(), fake.Arg(), godmenu.Items("one", "two")); err != nil {
+			t.Fatal(err)
+		}
+
+		fake.AssertCalls(2)
+		fake.AssertDone()
+		fake.AssertPresented("two", "one")
+		fake.AssertArgs("-b")
+		fake.AssertFlag("-p")
+		if len(rec.failures) != 5 {
+			t.Errorf("%q", rec.failures)
+		}
+	})
+}
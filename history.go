@@ -0,0 +1,344 @@
+package godmenu
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHistoryHalfLife is the time after which the weight of
+	// a selection in a History halves, unless HalfLife is set.
+	DefaultHistoryHalfLife = 7 * 24 * time.Hour
+	// DefaultHistoryMaxEntries is the number of selections that a
+	// History keeps, unless MaxEntries is set.
+	DefaultHistoryMaxEntries = 1000
+)
+
+// History records the selections made in a menu, and presents the
+// selections that were chosen most often, and most recently (their
+// "frecency"), first. Each selection weighs one when it is made, and
+// its weight halves with each HalfLife that passes. Histories are
+// safe to share between menus, and are written atomically; when
+// several processes share a history, the last to record wins.
+//
+// A history that cannot be read or written never fails a menu: the
+// menu opens unranked, and returns its selection, and the error is
+// passed to the ErrorHook.
+type History struct {
+	// Name identifies the menu, and names its history file in
+	// the godmenu directory under $XDG_STATE_HOME (or
+	// ~/.local/state).
+	Name string
+	// Path, when set, is the history file, rather than the file
+	// for the Name.
+	Path string
+	// HalfLife is the decay of the weight of selections. When
+	// zero, the history uses DefaultHistoryHalfLife.
+	HalfLife time.Duration
+	// MaxEntries limits the number of selections in the history,
+	// which drops the lowest ranked. When zero, the history uses
+	// DefaultHistoryMaxEntries.
+	MaxEntries int
+	// MaxAge, when set, drops selections which have not been
+	// made for longer.
+	MaxAge time.Duration
+	// ErrorHook, when set, receives the errors loading and
+	// recording the history during menus.
+	ErrorHook func(error)
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// HistoryEntry describes a selection in a History. Entries are
+// stored as JSON, one per line.
+type HistoryEntry struct {
+	Selection string    `json:"selection"`
+	Count     int       `json:"count"`
+	LastUsed  time.Time `json:"last_used"`
+	// Score is the selection's weight. The history stores the
+	// weight when the selection was last used, and reports the
+	// current weight.
+	Score float64 `json:"score"`
+}
+
+// NewHistory returns the history of the named menu, with the default
+// decay and limits.
+func NewHistory(name string) *History { return &History{Name: name} }
+
+// WithHistory ranks the selections by the history, in which the menu
+// records the selections that it returns.
+func WithHistory(h *History) Arg { return func(o *Options) { o.History = h } }
+
+// MenuHistory ranks the selections by the history of the named menu.
+// See History.
+func MenuHistory(name string) Arg { return WithHistory(NewHistory(name)) }
+
+// Entries returns the selections in the history, most highly ranked
+// first, with their current scores.
+func (h *History) Entries() ([]HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	now := h.clock()
+	h.rank(entries, now)
+	for idx := range entries {
+		entries[idx].Score = h.decay(entries[idx], now)
+	}
+	return entries, nil
+}
+
+// Record adds the selections to the history.
+func (h *History) Record(selections ...string) error {
+	if h == nil || len(selections) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries, err := h.load()
+	if err != nil {
+		return err
+	}
+
+	now := h.clock()
+	for _, sel := range selections {
+		idx := slices.IndexFunc(entries, func(e HistoryEntry) bool { return e.Selection == sel })
+		if idx < 0 {
+			entries = append(entries, HistoryEntry{Selection: sel})
+			idx = len(entries) - 1
+		}
+		entries[idx].Score = h.decay(entries[idx], now) + 1
+		entries[idx].Count++
+		entries[idx].LastUsed = now
+	}
+
+	return h.save(entries, now)
+}
+
+// Prune removes the selections for which keep returns false from the
+// history, and reports how many it removed.
+func (h *History) Prune(keep func(HistoryEntry) bool) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries, err := h.load()
+	if err != nil {
+		return 0, err
+	}
+
+	now := h.clock()
+	kept := slices.DeleteFunc(slices.Clone(entries), func(e HistoryEntry) bool {
+		e.Score = h.decay(e, now)
+		return !keep(e)
+	})
+	removed := len(entries) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, h.save(kept, now)
+}
+
+// Clear removes the history.
+func (h *History) Clear() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	path, err := h.path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (h *History) validate() error {
+	if h == nil {
+		return nil
+	}
+
+	var errs []error
+	if _, err := h.path(); err != nil {
+		errs = append(errs, err)
+	}
+	if h.HalfLife < 0 {
+		errs = append(errs, fmt.Errorf("invalid history half-life %s", h.HalfLife))
+	}
+	if h.MaxEntries < 0 {
+		errs = append(errs, fmt.Errorf("invalid history size %d", h.MaxEntries))
+	}
+	if h.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("invalid history age %s", h.MaxAge))
+	}
+	return errors.Join(errs...)
+}
+
+// scores returns the current score of each selection in the history,
+// for ranking the menu, and nil when the history cannot be loaded.
+func (h *History) scores() map[string]float64 {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries, err := h.load()
+	if err != nil {
+		h.report(fmt.Errorf("loading history: %w", err))
+		return nil
+	}
+
+	now := h.clock()
+	out := make(map[string]float64, len(entries))
+	for _, e := range entries {
+		out[e.Selection] = h.decay(e, now)
+	}
+	return out
+}
+
+// record adds the selections that a menu returned to the history.
+func (h *History) record(selections ...string) {
+	if err := h.Record(selections...); err != nil {
+		h.report(fmt.Errorf("recording selections in history: %w", err))
+	}
+}
+
+func (h *History) report(err error) {
+	if h.ErrorHook != nil {
+		h.ErrorHook(err)
+	}
+}
+
+func (h *History) path() (string, error) {
+	switch {
+	case h.Path != "":
+		return h.Path, nil
+	case h.Name == "" || h.Name == "." || h.Name == ".." || strings.ContainsAny(h.Name, `/\`):
+		return "", fmt.Errorf("invalid history name %q", h.Name)
+	}
+
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding history for %q: %w", h.Name, err)
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "godmenu", h.Name), nil
+}
+
+func (h *History) clock() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+// decay returns the score of the entry at the time.
+func (h *History) decay(e HistoryEntry, now time.Time) float64 {
+	halfLife := cmp.Or(h.HalfLife, DefaultHistoryHalfLife)
+	age := max(now.Sub(e.LastUsed), 0)
+	return e.Score * math.Exp2(-float64(age)/float64(halfLife))
+}
+
+// rank orders the entries by their scores at the time, most highly
+// ranked (and then most recently used) first.
+func (h *History) rank(entries []HistoryEntry, now time.Time) {
+	slices.SortStableFunc(entries, func(a, b HistoryEntry) int {
+		return cmp.Or(cmp.Compare(h.decay(b, now), h.decay(a, now)), b.LastUsed.Compare(a.LastUsed))
+	})
+}
+
+func (h *History) load() ([]HistoryEntry, error) {
+	path, err := h.path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// save writes the entries, most highly ranked first, dropping those
+// beyond the history's limits.
+func (h *History) save(entries []HistoryEntry, now time.Time) error {
+	path, err := h.path()
+	if err != nil {
+		return err
+	}
+
+	h.rank(entries, now)
+	if h.MaxAge > 0 {
+		entries = slices.DeleteFunc(entries, func(e HistoryEntry) bool { return now.Sub(e.LastUsed) > h.MaxAge })
+	}
+	if limit := cmp.Or(h.MaxEntries, DefaultHistoryMaxEntries); len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
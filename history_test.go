@@ -0,0 +1,327 @@
+package godmenu
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	newHistory := func(t *testing.T) (*History, *time.Time) {
+		now := start
+		h := &History{Path: filepath.Join(t.TempDir(), "history"), now: func() time.Time { return now }}
+		return h, &now
+	}
+	selections := func(t *testing.T, h *History) []string {
+		t.Helper()
+		entries, err := h.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]string, len(entries))
+		for idx := range entries {
+			out[idx] = entries[idx].Selection
+		}
+		return out
+	}
+
+	t.Run("Ranked", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if err := h.Record("c", "c", "c", "b"); err != nil {
+			t.Fatal(err)
+		}
+
+		runner := &mockRunner{stdout: "b\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), Items("a", "b", "c", "d"), WithHistory(h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "c\nb\na\nd" {
+			t.Errorf("%q", runner.stdin)
+		}
+		if res.Value != "b" || res.Index != 1 {
+			t.Errorf("%+v", res)
+		}
+		if got := selections(t, h); !slices.Equal(got, []string{"c", "b"}) {
+			t.Error(got)
+		}
+	})
+	t.Run("Sorted", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if err := h.Record("m"); err != nil {
+			t.Fatal(err)
+		}
+
+		runner := &mockRunner{stdout: "m\n"}
+		if _, err := Run(t.Context(), WithRunner(runner), Items("z", "m", "a", "q"), Sorted(), WithHistory(h)); err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "m\na\nq\nz" {
+			t.Errorf("%q", runner.stdin)
+		}
+	})
+	t.Run("Stream", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if err := h.Record("b"); err != nil {
+			t.Fatal(err)
+		}
+
+		runner := &mockRunner{stdout: "2 c\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), WithBackend(Rofi()), Items("a", "b"), FromSeq(slices.Values([]string{"c"})), WithHistory(h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "b\na\nc" || res.Value != "c" || res.Index != 2 {
+			t.Errorf("%q %+v", runner.stdin, res)
+		}
+	})
+	t.Run("Items", func(t *testing.T) {
+		h, _ := newHistory(t)
+		items := []Item{MakeItem("Firefox", "firefox"), MakeItem("Emacs", "emacs")}
+
+		runner := &mockRunner{stdout: "Emacs\n"}
+		out, err := Run(t.Context(), WithRunner(runner), WithItems(items...), WithHistory(h))
+		if err != nil || out != "emacs" {
+			t.Fatal(out, err)
+		}
+
+		runner = &mockRunner{stdout: "Emacs\n"}
+		if _, err := Run(t.Context(), WithRunner(runner), WithItems(items...), WithHistory(h)); err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "Emacs\nFirefox" {
+			t.Errorf("%q", runner.stdin)
+		}
+	})
+	t.Run("Decay", func(t *testing.T) {
+		h, now := newHistory(t)
+		h.HalfLife = 24 * time.Hour
+		if err := h.Record("old", "old", "old"); err != nil {
+			t.Fatal(err)
+		}
+
+		*now = now.Add(36 * time.Hour)
+		if err := h.Record("new"); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := h.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 || entries[0].Selection != "old" || entries[0].Count != 3 {
+			t.Fatalf("%+v", entries)
+		}
+		if score := entries[0].Score; score < 1.06 || score > 1.07 {
+			t.Error("three selections, one and a half half-lives ago, should weigh 3/2^1.5", score)
+		}
+
+		*now = now.Add(24 * time.Hour)
+		if got := selections(t, h); !slices.Equal(got, []string{"old", "new"}) {
+			t.Error(got)
+		}
+		if err := h.Record("new"); err != nil {
+			t.Fatal(err)
+		}
+		if got := selections(t, h); !slices.Equal(got, []string{"new", "old"}) {
+			t.Error(got)
+		}
+	})
+	t.Run("Limits", func(t *testing.T) {
+		h, now := newHistory(t)
+		h.MaxEntries = 2
+		if err := h.Record("a", "b", "b", "c", "c", "c"); err != nil {
+			t.Fatal(err)
+		}
+		if got := selections(t, h); !slices.Equal(got, []string{"c", "b"}) {
+			t.Error(got)
+		}
+
+		h.MaxAge = time.Hour
+		*now = now.Add(2 * time.Hour)
+		if err := h.Record("d"); err != nil {
+			t.Fatal(err)
+		}
+		if got := selections(t, h); !slices.Equal(got, []string{"d"}) {
+			t.Error(got)
+		}
+	})
+	t.Run("Prune", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if err := h.Record("keep", "keep", "drop", "drop-too"); err != nil {
+			t.Fatal(err)
+		}
+
+		n, err := h.Prune(func(e HistoryEntry) bool { return e.Score > 1.5 })
+		if err != nil || n != 2 {
+			t.Error(n, err)
+		}
+		if got := selections(t, h); !slices.Equal(got, []string{"keep"}) {
+			t.Error(got)
+		}
+
+		if err := h.Clear(); err != nil {
+			t.Fatal(err)
+		}
+		if got := selections(t, h); len(got) != 0 {
+			t.Error(got)
+		}
+		if err := h.Clear(); err != nil {
+			t.Error("clearing an empty history", err)
+		}
+	})
+	t.Run("Multi", func(t *testing.T) {
+		h, _ := newHistory(t)
+		out, err := RunMulti(t.Context(), WithBackend(&mockBackend{output: "b\nc\n"}), Items("a", "b", "c"), WithHistory(h))
+		if err != nil || len(out) != 2 {
+			t.Fatal(out, err)
+		}
+		if got := selections(t, h); len(got) != 2 || !slices.Contains(got, "b") || !slices.Contains(got, "c") {
+			t.Error(got)
+		}
+	})
+	t.Run("Fzf", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if err := h.Record("c"); err != nil {
+			t.Fatal(err)
+		}
+
+		runner := &mockRunner{stdout: "a\nc\n"}
+		res, err := Fzf{}.Select(t.Context(), *ResolveOptions(WithRunner(runner), Items("a", "b", "c"), MultiSelect(), WithHistory(h)))
+		if err != nil || !slices.Equal(res.Selections, []string{"a", "c"}) {
+			t.Fatal(res, err)
+		}
+		if runner.stdin != "c\na\nb" {
+			t.Errorf("%q", runner.stdin)
+		}
+		if got := selections(t, h); !slices.Equal(got, []string{"c", "a"}) {
+			t.Error(got)
+		}
+	})
+	t.Run("Failures", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if _, err := Run(t.Context(), WithBackend(&mockBackend{err: ErrCanceled}), Items("a"), WithHistory(h)); !errors.Is(err, ErrCanceled) {
+			t.Error(err)
+		}
+		if _, err := Run(t.Context(), WithBackend(&mockBackend{output: "z"}), Items("a"), RequireMatch(), WithHistory(h)); !errors.Is(err, ErrSelectionUnknown) {
+			t.Error(err)
+		}
+		if got := selections(t, h); len(got) != 0 {
+			t.Error(got)
+		}
+	})
+	t.Run("Confirmation", func(t *testing.T) {
+		h, _ := newHistory(t)
+		mock := &mockBackend{output: "accept"}
+		out, err := Run(t.Context(), WithBackend(mock), Items("one"), WithHistory(h), ConfirmSubstituion(),
+			func(o *Options) { o.Transform = strings.ToUpper })
+		if err != nil || out != "ACCEPT" {
+			t.Fatal(out, err)
+		}
+		if got := selections(t, h); !slices.Equal(got, []string{"ACCEPT"}) {
+			t.Error(got)
+		}
+	})
+	t.Run("StateHome", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("XDG_STATE_HOME", dir)
+
+		if _, err := Run(t.Context(), WithBackend(&mockBackend{output: "a"}), Items("a"), MenuHistory("launcher")); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "godmenu", "launcher"))
+		if err != nil || !strings.Contains(string(data), `"selection":"a","count":1`) {
+			t.Error(string(data), err)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for name, h := range map[string]*History{
+			"Name":     NewHistory("../escape"),
+			"Unnamed":  {},
+			"HalfLife": {Name: "menu", HalfLife: -time.Hour},
+			"Size":     {Name: "menu", MaxEntries: -1},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Run(t.Context(), WithBackend(&mockBackend{output: "a"}), Items("a"), WithHistory(h))
+				if !errors.Is(err, ErrConfigurationInvalid) {
+					t.Error(err)
+				}
+			})
+		}
+	})
+	t.Run("Corrupt", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if err := os.WriteFile(h.Path, []byte("{\"selection\":\"a\"}\nnot json\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var errs []error
+		h.ErrorHook = func(err error) { errs = append(errs, err) }
+
+		out, err := Run(t.Context(), WithBackend(&mockBackend{output: "a"}), Items("a"), WithHistory(h))
+		if err != nil || out != "a" {
+			t.Fatal(out, err)
+		}
+		if len(errs) != 2 || !strings.Contains(errs[0].Error(), h.Path+":2") || !strings.Contains(errs[1].Error(), "recording") {
+			t.Error(errs)
+		}
+	})
+	t.Run("Unwritable", func(t *testing.T) {
+		h, _ := newHistory(t)
+		if err := os.WriteFile(h.Path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		h.Path = filepath.Join(h.Path, "history")
+
+		var errs []error
+		h.ErrorHook = func(err error) { errs = append(errs, err) }
+
+		out, err := Run(t.Context(), WithBackend(&mockBackend{output: "b"}), Items("a", "b"), WithHistory(h))
+		if err != nil || out != "b" {
+			t.Fatal(out, err)
+		}
+		if len(errs) != 2 {
+			t.Error(errs)
+		}
+
+		h.ErrorHook = nil
+		if _, err := Run(t.Context(), WithBackend(&mockBackend{output: "b"}), Items("a", "b"), WithHistory(h)); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		h, _ := newHistory(t)
+		opts := Options{Selections: []string{"a", "b"}, Runner: lastRunner{}, History: h}
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := Do(t.Context(), opts); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// the ranking changes which selection is last, and so
+		// which is chosen, but every selection is recorded.
+		entries, err := h.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		total := 0
+		for _, e := range entries {
+			total += e.Count
+		}
+		if total != 8 {
+			t.Error(entries)
+		}
+	})
+}
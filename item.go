@@ -0,0 +1,40 @@
+package godmenu
+
+import "strings"
+
+// DescriptionSeparator separates an Item's label from its description
+// in the menu.
+const DescriptionSeparator = " — "
+
+// Item is a selection which is presented with a label, and optionally
+// a description, but which returns a different value when selected:
+// for instance, an item can show "Firefox — web browser" and return
+// "firefox".
+type Item struct {
+	// Label is the text shown in the menu.
+	Label string
+	// Description, when set, is shown after the label.
+	Description string
+	// Value is returned when the item is selected. When empty,
+	// the label is returned.
+	Value string
+	// Metadata is not used by godmenu, but allows callers to
+	// associate data with the item.
+	Metadata map[string]string
+	// Source is the name of the Source which produced the item,
+	// and is set when the source is loaded.
+	Source string
+}
+
+// MakeItem returns an Item which shows label and returns value.
+func MakeItem(label, value string) Item { return Item{Label: label, Value: value} }
+
+func (it Item) value() string { return loadDefault(it.Value, strings.TrimSpace(it.Label)) }
+
+func (it Item) display() string {
+	label := strings.TrimSpace(it.Label)
+	if it.Description == "" || label == "" {
+		return label
+	}
+	return label + DescriptionSeparator + strings.TrimSpace(it.Description)
+}
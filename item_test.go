@@ -0,0 +1,119 @@
+package godmenu
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestItems(t *testing.T) {
+	items := []Item{
+		{Label: "Firefox", Description: "web browser", Value: "firefox"},
+		{Label: "Emacs", Value: "emacsclient -c"},
+		{Label: "xterm"},
+	}
+
+	t.Run("Display", func(t *testing.T) {
+		st := newItemSet(items, 0)
+		if rendered := string(st.rendered(false)); rendered != "Firefox — web browser\nEmacs\nxterm" {
+			t.Errorf("%q", rendered)
+		}
+	})
+	t.Run("Value", func(t *testing.T) {
+		st := newItemSet(items, 0).withRequireMatch(true)
+		for output, expected := range map[string]string{
+			"Firefox — web browser\n": "firefox",
+			"Emacs":                   "emacsclient -c",
+			"xterm":                   "xterm",
+		} {
+			out, err := st.processOutput([]byte(output), nil)
+			if err != nil || out != expected {
+				t.Error(output, out, err)
+			}
+		}
+		if out, err := st.processOutput([]byte("Firefox"), nil); !errors.Is(err, ErrSelectionUnknown) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("FreeText", func(t *testing.T) {
+		st := newItemSet(items, 0).withRequireMatch(false)
+		out, err := st.processOutput([]byte("vim"), nil)
+		if err != nil || out != "vim" {
+			t.Error(out, err)
+		}
+		if st.matches("vim") || !st.matches("firefox") {
+			t.Error("matches should consider values")
+		}
+	})
+	t.Run("Sorted", func(t *testing.T) {
+		mb := &mockBackend{output: "Emacs"}
+		out, err := Run(t.Context(), WithBackend(mb), WithItems(items...), Sorted())
+		if err != nil || out != "emacsclient -c" {
+			t.Error(out, err)
+		}
+		if mb.input != "Emacs\nFirefox — web browser\nxterm" {
+			t.Errorf("%q", mb.input)
+		}
+	})
+	t.Run("WithSelections", func(t *testing.T) {
+		mb := &mockBackend{output: "vim"}
+		out, err := Run(t.Context(), WithBackend(mb), Items("vim"), WithItems(items[0]), RequireMatch())
+		if err != nil || out != "vim" {
+			t.Error(out, err)
+		}
+		if mb.input != "vim\nFirefox — web browser" {
+			t.Errorf("%q", mb.input)
+		}
+	})
+	t.Run("DuplicateLabels", func(t *testing.T) {
+		mb := &mockBackend{output: "Firefox (firefox-esr)"}
+		out, err := Run(t.Context(),
+			WithBackend(mb),
+			WithItems(MakeItem("Firefox", "firefox"), MakeItem("Firefox", "firefox-esr")),
+			Sorted(),
+		)
+		if err != nil || out != "firefox-esr" {
+			t.Error(out, err)
+		}
+		if mb.input != "Firefox (firefox)\nFirefox (firefox-esr)" {
+			t.Errorf("%q", mb.input)
+		}
+	})
+	t.Run("Duplicates", func(t *testing.T) {
+		out, err := Run(t.Context(), WithBackend(&mockBackend{}), WithItems(MakeItem("Firefox", "firefox"), MakeItem("Firefox", "firefox")))
+		if !errors.Is(err, ErrConfigurationInvalid) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("ConfirmSubstitution", func(t *testing.T) {
+		mb := &mockBackend{output: "Emacs"}
+		out, err := Run(t.Context(),
+			WithBackend(mb),
+			WithItems(items...),
+			func(o *Options) { o.Transform = func(s string) string { return s } },
+			ConfirmSubstituion(),
+		)
+		if err != nil || out != "emacsclient -c" {
+			t.Error(out, err)
+		}
+		if mb.input == "emacsclient -c\naccept\nreject" {
+			t.Error("selecting an item should not require confirmation")
+		}
+	})
+	t.Run("ConfirmationMenu", func(t *testing.T) {
+		mb := &mockBackend{output: "accept"}
+		out, err := Run(t.Context(),
+			WithBackend(mb),
+			WithItems(MakeItem("Firefox", "firefox")),
+			WithSources(StaticSource("more", "Emacs")),
+			func(o *Options) { o.Transform = strings.ToUpper },
+			ConfirmSubstituion(),
+		)
+		if err != nil || out != "ACCEPT" {
+			t.Error(out, err)
+		}
+		if mb.input != "ACCEPT\naccept\nreject" {
+			t.Errorf("the confirmation menu should only offer the substitution, not %q", mb.input)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package godmenu
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMultiSelect(t *testing.T) {
+	t.Run("DMenu", func(t *testing.T) {
+		path, _ := standIn(t, "three\none\n", 0)
+
+		out, err := RunMulti(t.Context(), DMenuPath(path), Items("one", "two", "three"), RequireMatch())
+		if err != nil || !slices.Equal(out, []string{"three", "one"}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Rofi", func(t *testing.T) {
+		path, record := standIn(t, "two\nthree\n", 0)
+
+		out, err := RunMulti(t.Context(), WithBackend(Rofi()), DMenuPath(path), Items("one", "two", "three"))
+		if err != nil || !slices.Equal(out, []string{"two", "three"}) {
+			t.Error(out, err)
+		}
+		if args, _ := record(); !slices.Contains(args, "-multi-select") {
+			t.Error(args)
+		}
+	})
+	t.Run("Fzf", func(t *testing.T) {
+		path, record := standIn(t, "two\n", 0)
+
+		out, err := RunMulti(t.Context(), WithBackend(Fzf{}), DMenuPath(path), Items("one", "two", "three"))
+		if err != nil || !slices.Equal(out, []string{"two"}) {
+			t.Error(out, err)
+		}
+		if args, _ := record(); !slices.Contains(args, "--multi") {
+			t.Error(args)
+		}
+	})
+	t.Run("Plain", func(t *testing.T) {
+		backend := Plain(strings.NewReader("3, 1\n"), &bytes.Buffer{})
+		out, err := RunMulti(t.Context(), WithBackend(backend), Items("one", "two", "three"))
+		if err != nil || !slices.Equal(out, []string{"three", "one"}) {
+			t.Error(out, err)
+		}
+
+		backend = Plain(strings.NewReader("two\n"), &bytes.Buffer{})
+		out, err = RunMulti(t.Context(), WithBackend(backend), Items("one", "two", "three"))
+		if err != nil || !slices.Equal(out, []string{"two"}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Items", func(t *testing.T) {
+		mb := &mockBackend{output: "Firefox\nEmacs\n"}
+		out, err := RunMulti(t.Context(), WithBackend(mb), WithItems(MakeItem("Emacs", "emacs"), MakeItem("Firefox", "firefox")))
+		if err != nil || !slices.Equal(out, []string{"firefox", "emacs"}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("RequireMatch", func(t *testing.T) {
+		mb := &mockBackend{output: "one\nfour\n"}
+		out, err := RunMulti(t.Context(), WithBackend(mb), Items("one", "two"), RequireMatch())
+		if !errors.Is(err, ErrSelectionUnknown) || out != nil {
+			t.Error(out, err)
+		}
+
+		mb = &mockBackend{output: "one\nfour\n"}
+		out, err = RunMulti(t.Context(), WithBackend(mb), Items("one", "two"))
+		if err != nil || !slices.Equal(out, []string{"one", "four"}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		out, err := RunMulti(t.Context(), WithBackend(&mockBackend{output: "\n\n"}), Items("one", "two"))
+		if !errors.Is(err, ErrSelectionMissing) || out != nil {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		path, _ := standIn(t, "one\n", 0)
+		for _, backend := range []Backend{Fuzzel(), Tofi(), Wofi(), Bemenu(), Wmenu(), Picker()} {
+			out, err := RunMulti(t.Context(), WithBackend(backend), DMenuPath(path), Items("one", "two"))
+			if !errors.Is(err, ErrConfigurationInvalid) || out != nil {
+				t.Error(backend.Name(), out, err)
+			}
+		}
+	})
+	t.Run("Do", func(t *testing.T) {
+		out, err := Run(t.Context(), WithBackend(&mockBackend{output: "one"}), Items("one", "two"), MultiSelect())
+		if !errors.Is(err, ErrConfigurationInvalid) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("ConfirmSubstitution", func(t *testing.T) {
+		out, err := RunMulti(t.Context(),
+			WithBackend(&mockBackend{output: "one"}),
+			Items("one", "two"),
+			func(o *Options) { o.Transform = strings.ToUpper },
+			ConfirmSubstituion(),
+		)
+		if !errors.Is(err, ErrConfigurationInvalid) || out != nil {
+			t.Error(out, err)
+		}
+	})
+}
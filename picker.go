@@ -0,0 +1,397 @@
+package godmenu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultPickerLines is the number of selections the built-in picker
+// shows when the Lines flag is not set.
+const DefaultPickerLines = 10
+
+// Picker returns a backend which draws the menu in the terminal
+// (/dev/tty) using a built-in fuzzy finder, and so works where no
+// launcher is installed. The up and down arrows (or ctrl-p and
+// ctrl-n) move the highlight, tab completes the query, enter accepts
+// the highlighted selection (or, when nothing matches and a match is
+// not required, the query), and escape or ctrl-c dismiss the menu.
+// Colors, fonts and the display flags do not apply.
+func Picker() Backend { return pickerBackend{device: "/dev/tty"} }
+
+type pickerBackend struct{ device string }
+
+func (pickerBackend) Name() string { return "picker" }
+
+func (pb pickerBackend) Validate(opts *Options) error {
+	if opts.Multi {
+		return errors.New("picker does not support multiple selections")
+	}
+
+	tty, err := os.OpenFile(pb.device, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("picker requires a terminal: %w", err)
+	}
+	return tty.Close()
+}
+
+func (pickerBackend) Render(w io.Writer, selections iter.Seq[string]) error {
+	return dmenuBackend.Render(w, selections)
+}
+
+func (pickerBackend) Parse(out []byte, err error) (Response, error) {
+	return Response{Output: out}, err
+}
+
+func (pb pickerBackend) Run(ctx context.Context, opts *Options, input io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	tty, err := os.OpenFile(pb.device, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+
+	conn, err := tty.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	restore, err := makeRaw(conn)
+	if err != nil {
+		return nil, fmt.Errorf("setting up terminal: %w", err)
+	}
+	defer restore()
+
+	// interrupt the blocked read when the context ends.
+	stop := context.AfterFunc(ctx, func() { _ = tty.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	p := newPicker(strings.Split(string(data), "\n"), opts)
+	if cols, rows, err := terminalSize(conn); err == nil {
+		p.width = cols
+		p.lines = max(1, min(p.lines, rows-1))
+	}
+
+	out, err := p.run(tty, tty)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return []byte(out), err
+}
+
+// picker holds the state of the built-in menu. The matches are
+// indexes into the items, ordered by how well they match the query.
+type picker struct {
+	items         []string
+	prompt        string
+	lines         int
+	width         int
+	caseSensitive bool
+	requireMatch  bool
+
+	query   []rune
+	matches []int
+	cursor  int
+	offset  int
+}
+
+func newPicker(items []string, opts *Options) *picker {
+	p := &picker{
+		items:         items,
+		prompt:        opts.Flags.Prompt,
+		lines:         opts.Flags.Lines,
+		caseSensitive: opts.Flags.CaseSensitive,
+		requireMatch:  opts.RequireMatch,
+	}
+	if p.lines <= 0 {
+		p.lines = DefaultPickerLines
+	}
+	p.filter()
+	return p
+}
+
+type key int
+
+const (
+	keyRune key = iota
+	keyEnter
+	keyCancel
+	keyBackspace
+	keyDeleteWord
+	keyClear
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyTab
+	keyUnknown
+)
+
+// run draws the menu to out and handles keys from in until the user
+// accepts a selection or dismisses the menu.
+func (p *picker) run(in io.Reader, out io.Writer) (string, error) {
+	keys := bufio.NewReader(in)
+	w := bufio.NewWriter(out)
+	defer func() { _, _ = io.WriteString(out, "\r\x1b[J") }()
+
+	for {
+		p.draw(w)
+		if err := w.Flush(); err != nil {
+			return "", err
+		}
+
+		k, r, err := readKey(keys)
+		if err != nil {
+			return "", err
+		}
+
+		if sel, done := p.handle(k, r); done {
+			if sel == "" {
+				return "", canceled("picker", nil)
+			}
+			return sel, nil
+		} else if k == keyEnter {
+			_, _ = w.WriteString("\a")
+		}
+	}
+}
+
+// handle updates the state for a key, reporting the selection when
+// the menu is finished. An empty selection means the user dismissed
+// the menu.
+func (p *picker) handle(k key, r rune) (string, bool) {
+	switch k {
+	case keyRune:
+		p.query = append(p.query, r)
+		p.filter()
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case keyDeleteWord:
+		trimmed := strings.TrimRightFunc(string(p.query), unicode.IsSpace)
+		idx := strings.LastIndexFunc(trimmed, unicode.IsSpace)
+		p.query = []rune(trimmed[:idx+1])
+		p.filter()
+	case keyClear:
+		p.query = p.query[:0]
+		p.filter()
+	case keyUp:
+		p.move(-1)
+	case keyDown:
+		p.move(1)
+	case keyPageUp:
+		p.move(-p.lines)
+	case keyPageDown:
+		p.move(p.lines)
+	case keyTab:
+		if len(p.matches) > 0 {
+			p.query = []rune(p.items[p.matches[p.cursor]])
+			p.filter()
+		}
+	case keyCancel:
+		return "", true
+	case keyEnter:
+		switch {
+		case len(p.matches) > 0:
+			return p.items[p.matches[p.cursor]], true
+		case !p.requireMatch && len(p.query) > 0:
+			return string(p.query), true
+		}
+	}
+
+	return "", false
+}
+
+func (p *picker) move(n int) {
+	if len(p.matches) == 0 {
+		return
+	}
+
+	p.cursor = max(0, min(len(p.matches)-1, p.cursor+n))
+
+	switch {
+	case p.cursor < p.offset:
+		p.offset = p.cursor
+	case p.cursor >= p.offset+p.lines:
+		p.offset = p.cursor - p.lines + 1
+	}
+}
+
+// filter recomputes the matches for the query, keeping items which
+// fuzzy match it, best matches first.
+func (p *picker) filter() {
+	p.cursor, p.offset = 0, 0
+	p.matches = p.matches[:0]
+
+	pattern := p.query
+	if !p.caseSensitive {
+		pattern = []rune(strings.ToLower(string(pattern)))
+	}
+
+	scores := make(map[int]int, len(p.items))
+	for idx, item := range p.items {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		if !p.caseSensitive {
+			item = strings.ToLower(item)
+		}
+		if score, ok := fuzzyScore([]rune(item), pattern); ok {
+			scores[idx] = score
+			p.matches = append(p.matches, idx)
+		}
+	}
+
+	slices.SortStableFunc(p.matches, func(a, b int) int { return scores[b] - scores[a] })
+}
+
+// fuzzyScore reports if the runes of pattern appear in text in order,
+// and scores the match, favoring consecutive runes and runes at the
+// start of words.
+func fuzzyScore(text, pattern []rune) (int, bool) {
+	var score, pos int
+	last := -2
+	for _, r := range pattern {
+		idx := slices.Index(text[pos:], r)
+		if idx < 0 {
+			return 0, false
+		}
+		idx += pos
+
+		score++
+		switch {
+		case idx == last+1:
+			score += 5
+		case idx == 0 || strings.ContainsRune(" -_/.:", text[idx-1]):
+			score += 3
+		}
+
+		last, pos = idx, idx+1
+	}
+
+	return score, true
+}
+
+// draw renders the prompt, query and visible matches, leaving the
+// cursor at the end of the query.
+func (p *picker) draw(w *bufio.Writer) {
+	_, _ = w.WriteString("\r\x1b[J")
+
+	line := p.query
+	if p.prompt != "" {
+		line = []rune(p.prompt + " " + string(p.query))
+	}
+	_, _ = w.WriteString(p.truncate(string(line)))
+
+	end := min(len(p.matches), p.offset+p.lines)
+	for pos := p.offset; pos < end; pos++ {
+		item := p.truncate(p.items[p.matches[pos]])
+		if pos == p.cursor {
+			_, _ = fmt.Fprintf(w, "\r\n\x1b[7m%s\x1b[0m", item)
+		} else {
+			_, _ = fmt.Fprintf(w, "\r\n%s", item)
+		}
+	}
+
+	if shown := end - p.offset; shown > 0 {
+		_, _ = fmt.Fprintf(w, "\x1b[%dA", shown)
+	}
+	column := len(line)
+	if p.width > 0 {
+		column = min(column, p.width-1)
+	}
+	_, _ = fmt.Fprintf(w, "\r\x1b[%dG", column+1)
+}
+
+func (p *picker) truncate(s string) string {
+	if p.width <= 0 {
+		return s
+	}
+	if r := []rune(s); len(r) >= p.width {
+		return string(r[:p.width-1])
+	}
+	return s
+}
+
+// readKey reads one keypress, decoding the escape sequences that
+// terminals send for the arrow and paging keys. A lone escape, which
+// is not followed by the rest of a sequence, dismisses the menu.
+func readKey(r *bufio.Reader) (key, rune, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return keyUnknown, 0, err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return keyEnter, c, nil
+	case 3, 7: // ctrl-c, ctrl-g
+		return keyCancel, c, nil
+	case 127, 8: // backspace, ctrl-h
+		return keyBackspace, c, nil
+	case 23: // ctrl-w
+		return keyDeleteWord, c, nil
+	case 21: // ctrl-u
+		return keyClear, c, nil
+	case 16: // ctrl-p
+		return keyUp, c, nil
+	case 14: // ctrl-n
+		return keyDown, c, nil
+	case '\t':
+		return keyTab, c, nil
+	case 27:
+		if r.Buffered() == 0 {
+			return keyCancel, c, nil
+		}
+		return readEscape(r)
+	}
+
+	if unicode.IsControl(c) {
+		return keyUnknown, c, nil
+	}
+
+	return keyRune, c, nil
+}
+
+func readEscape(r *bufio.Reader) (key, rune, error) {
+	var seq bytes.Buffer
+	for r.Buffered() > 0 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return keyUnknown, 0, err
+		}
+		seq.WriteByte(c)
+		// sequences end with a letter or a tilde.
+		if seq.Len() > 1 && (c == '~' || unicode.IsLetter(rune(c))) {
+			break
+		}
+	}
+
+	switch seq.String() {
+	case "[A", "OA":
+		return keyUp, 0, nil
+	case "[B", "OB":
+		return keyDown, 0, nil
+	case "[5~":
+		return keyPageUp, 0, nil
+	case "[6~":
+		return keyPageDown, 0, nil
+	default:
+		return keyUnknown, 0, nil
+	}
+}
@@ -0,0 +1,132 @@
+package godmenu
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPicker(t *testing.T) {
+	items := []string{"alpha", "bravo", "charlie", "delta", "echo"}
+	run := func(t *testing.T, keys string, args ...Arg) (string, string, error) {
+		t.Helper()
+		var screen bytes.Buffer
+		p := newPicker(items, ResolveOptions(args...))
+		out, err := p.run(strings.NewReader(keys), &screen)
+		return out, screen.String(), err
+	}
+
+	t.Run("Enter", func(t *testing.T) {
+		out, _, err := run(t, "\r")
+		if err != nil || out != "alpha" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Filter", func(t *testing.T) {
+		out, _, err := run(t, "lt\r")
+		if err != nil || out != "delta" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Arrows", func(t *testing.T) {
+		out, _, err := run(t, "\x1b[B\x1b[B\x1b[A\x0e\r")
+		if err != nil || out != "charlie" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Backspace", func(t *testing.T) {
+		out, _, err := run(t, "echx\x7f\r")
+		if err != nil || out != "echo" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Cancel", func(t *testing.T) {
+		for _, keys := range []string{"\x1b", "br\x03"} {
+			out, _, err := run(t, keys)
+			if !errors.Is(err, ErrSelectionMissing) || out != "" {
+				t.Error(out, err)
+			}
+		}
+	})
+	t.Run("EndOfInput", func(t *testing.T) {
+		out, _, err := run(t, "br")
+		if !errors.Is(err, io.EOF) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("FreeText", func(t *testing.T) {
+		out, _, err := run(t, "zulu\r")
+		if err != nil || out != "zulu" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("RequireMatch", func(t *testing.T) {
+		out, screen, err := run(t, "zulu\r\x15ch\r", RequireMatch())
+		if err != nil || out != "charlie" {
+			t.Error(out, err)
+		}
+		if !strings.Contains(screen, "\a") {
+			t.Error("expected the picker to ring the bell")
+		}
+	})
+	t.Run("Tab", func(t *testing.T) {
+		out, _, err := run(t, "ch\t\r")
+		if err != nil || out != "charlie" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("CaseSensitive", func(t *testing.T) {
+		out, _, err := run(t, "A\r", CaseSensitive())
+		if err != nil || out != "A" {
+			t.Error(out, err)
+		}
+		out, _, err = run(t, "A\r")
+		if err != nil || out != "alpha" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Scrolling", func(t *testing.T) {
+		p := newPicker(items, ResolveOptions(MenuLines(2)))
+		for range 3 {
+			p.handle(keyDown, 0)
+		}
+		if p.cursor != 3 || p.offset != 2 {
+			t.Error(p.cursor, p.offset)
+		}
+		p.handle(keyPageUp, 0)
+		if p.cursor != 1 || p.offset != 1 {
+			t.Error(p.cursor, p.offset)
+		}
+		p.handle(keyPageDown, 0)
+		p.handle(keyPageDown, 0)
+		if p.cursor != 4 || p.offset != 3 {
+			t.Error(p.cursor, p.offset)
+		}
+	})
+	t.Run("Draw", func(t *testing.T) {
+		_, screen, _ := run(t, "\r", MenuPrompt("pick:"), MenuLines(2))
+		if !strings.Contains(screen, "pick: ") {
+			t.Errorf("%q", screen)
+		}
+		if !strings.Contains(screen, "\x1b[7malpha\x1b[0m") || !strings.Contains(screen, "bravo") {
+			t.Errorf("%q", screen)
+		}
+		if strings.Contains(screen, "charlie") {
+			t.Errorf("only two lines should be drawn: %q", screen)
+		}
+	})
+	t.Run("Ranking", func(t *testing.T) {
+		p := newPicker([]string{"fabulous-ordering", "foo", "bar-foo"}, ResolveOptions())
+		for _, r := range "foo" {
+			p.handle(keyRune, r)
+		}
+		if len(p.matches) != 3 || p.items[p.matches[0]] != "foo" || p.items[p.matches[2]] != "fabulous-ordering" {
+			for _, idx := range p.matches {
+				t.Log(p.items[idx])
+			}
+			t.Error("unexpected ranking")
+		}
+	})
+}
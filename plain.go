@@ -0,0 +1,130 @@
+package godmenu
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Plain returns a backend which writes a numbered list of the
+// selections and the prompt to out, and reads the user's answer, one
+// line, from in. Answers are either the number of a selection or the
+// text of the selection. When a match is required, unknown answers
+// are rejected and the prompt is repeated. An empty answer cancels
+// the menu, and the end of the input means the user made no
+// selection.
+//
+// For multiple selections, answers may list the numbers of several
+// selections, separated by spaces or commas.
+//
+// Plain needs neither a display nor a terminal, and works with
+// piped or scripted input. The backend buffers reads from in, so
+// use the same backend for every menu that reads from in.
+func Plain(in io.Reader, out io.Writer) Backend {
+	return &plainBackend{in: bufio.NewReader(in), out: out}
+}
+
+type plainBackend struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (*plainBackend) Name() string            { return "plain" }
+func (*plainBackend) Validate(*Options) error { return nil }
+
+func (*plainBackend) Render(w io.Writer, selections iter.Seq[string]) error {
+	return dmenuBackend.Render(w, selections)
+}
+
+func (*plainBackend) Parse(out []byte, err error) (Response, error) {
+	return Response{Output: out}, err
+}
+
+func (pb *plainBackend) Run(ctx context.Context, opts *Options, input io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	items := strings.Split(string(data), "\n")
+
+	w := bufio.NewWriter(pb.out)
+	width := len(strconv.Itoa(len(items)))
+	for idx, item := range items {
+		fmt.Fprintf(w, "%*d) %s\n", width, idx+1, item)
+	}
+
+	prompt := loadDefault(opts.Flags.Prompt, ">")
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(w, "%s ", prompt)
+		if err := w.Flush(); err != nil {
+			return nil, err
+		}
+
+		line, err := pb.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		switch {
+		case answer == "" && err == io.EOF:
+			return nil, fmt.Errorf("end of input without a selection: %w", ErrSelectionMissing)
+		case answer == "" && err != nil:
+			return nil, err
+		case answer == "":
+			return nil, canceled("plain", nil)
+		}
+
+		if opts.Multi {
+			if sels, ok := plainNumbers(items, answer); ok {
+				return []byte(strings.Join(sels, "\n")), nil
+			}
+		}
+
+		if sel, ok := plainAnswer(items, answer); ok || !opts.RequireMatch {
+			return []byte(sel), nil
+		}
+
+		fmt.Fprintf(w, "%q is not one of the selections\n", answer)
+		if err != nil {
+			return nil, fmt.Errorf("end of input without a selection: %w", ErrSelectionMissing)
+		}
+	}
+}
+
+// plainAnswer resolves an answer to a selection: the text of a
+// selection takes precedence over the number of a selection, so that
+// numeric selections are unambiguous.
+func plainAnswer(items []string, answer string) (string, bool) {
+	if slices.Contains(items, answer) {
+		return answer, true
+	}
+
+	if num, err := strconv.Atoi(answer); err == nil && num >= 1 && num <= len(items) {
+		return items[num-1], true
+	}
+
+	return answer, false
+}
+
+// plainNumbers resolves an answer which lists the numbers of one or
+// more selections.
+func plainNumbers(items []string, answer string) ([]string, bool) {
+	fields := strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	out := make([]string, 0, len(fields))
+	for _, field := range fields {
+		num, err := strconv.Atoi(field)
+		if err != nil || num < 1 || num > len(items) {
+			return nil, false
+		}
+		out = append(out, items[num-1])
+	}
+	return out, len(out) > 0
+}
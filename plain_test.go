@@ -0,0 +1,106 @@
+package godmenu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPlain(t *testing.T) {
+	run := func(t *testing.T, answers string, args ...Arg) (string, string, error) {
+		t.Helper()
+		var screen bytes.Buffer
+		backend := Plain(strings.NewReader(answers), &screen)
+		out, err := Run(t.Context(), append([]Arg{WithBackend(backend), Items("one", "two", "3")}, args...)...)
+		return out, screen.String(), err
+	}
+
+	t.Run("Number", func(t *testing.T) {
+		out, screen, err := run(t, "2\n", MenuPrompt("pick:"))
+		if err != nil || out != "two" {
+			t.Error(out, err)
+		}
+		if screen != "1) one\n2) two\n3) 3\npick: " {
+			t.Errorf("%q", screen)
+		}
+	})
+	t.Run("Literal", func(t *testing.T) {
+		out, _, err := run(t, "one\n")
+		if err != nil || out != "one" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("LiteralPrecedence", func(t *testing.T) {
+		out, _, err := run(t, "3\n", Sorted())
+		if err != nil || out != "3" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("WithoutNewline", func(t *testing.T) {
+		out, _, err := run(t, "1")
+		if err != nil || out != "one" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("FreeText", func(t *testing.T) {
+		out, _, err := run(t, "four\n")
+		if err != nil || out != "four" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("RequireMatch", func(t *testing.T) {
+		out, screen, err := run(t, "four\n9\ntwo\n", RequireMatch())
+		if err != nil || out != "two" {
+			t.Error(out, err)
+		}
+		if strings.Count(screen, "> ") != 3 || !strings.Contains(screen, `"four" is not one of the selections`) {
+			t.Errorf("%q", screen)
+		}
+	})
+	t.Run("RequireMatchEndOfInput", func(t *testing.T) {
+		out, _, err := run(t, "four", RequireMatch())
+		if !errors.Is(err, ErrSelectionMissing) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		for _, answers := range []string{"", "\n", "  \n"} {
+			out, _, err := run(t, answers)
+			if !errors.Is(err, ErrSelectionMissing) || out != "" {
+				t.Error(out, err)
+			}
+		}
+	})
+	t.Run("Transform", func(t *testing.T) {
+		out, _, err := run(t, "1\n", func(o *Options) { o.Transform = strings.ToUpper })
+		if err != nil || out != "ONE" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("ConfirmSubstitution", func(t *testing.T) {
+		transform := func(o *Options) { o.Transform = func(s string) string { return s + "!" } }
+
+		out, screen, err := run(t, "1\naccept\n", transform, ConfirmSubstituion())
+		if err != nil || out != "one!" {
+			t.Error(out, err)
+		}
+		if !strings.Contains(screen, ") one!\n") {
+			t.Errorf("confirmation should present the substitution: %q", screen)
+		}
+
+		out, _, err = run(t, "1\nreject\n", transform, ConfirmSubstituion())
+		if !errors.Is(err, ErrSelectionRejected) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		out, err := Run(ctx, WithBackend(Plain(strings.NewReader("1\n"), &bytes.Buffer{})), Items("one"))
+		if !errors.Is(err, context.Canceled) || out != "" {
+			t.Error(out, err)
+		}
+	})
+}
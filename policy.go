@@ -0,0 +1,64 @@
+package godmenu
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// SelectionPolicy describes how godmenu normalizes the selections
+// before presenting them. Selections are always trimmed of
+// surrounding whitespace; by default, blank and duplicate selections
+// are configuration errors. Combine policies with "|".
+type SelectionPolicy uint
+
+const (
+	// DropBlanks omits selections which are empty (after
+	// normalization), rather than rejecting them.
+	DropBlanks SelectionPolicy = 1 << iota
+	// CollapseWhitespace replaces each run of whitespace within
+	// a selection with a single space.
+	CollapseWhitespace
+	// StripControl replaces tabs and line breaks with spaces, and
+	// removes all other control characters.
+	StripControl
+	// DedupeKeepFirst keeps only the first of duplicate
+	// selections, in its position.
+	DedupeKeepFirst
+	// DedupeKeepLast keeps only the last of duplicate
+	// selections, in its position.
+	DedupeKeepLast
+)
+
+// normalize returns the selection as it is presented.
+func (p SelectionPolicy) normalize(sel string) string {
+	if p&StripControl != 0 {
+		sel = strings.Map(func(r rune) rune {
+			switch {
+			case !unicode.IsControl(r):
+				return r
+			case unicode.IsSpace(r):
+				return ' '
+			default:
+				return -1
+			}
+		}, sel)
+	}
+	if p&CollapseWhitespace != 0 {
+		sel = strings.Join(strings.Fields(sel), " ")
+	}
+	return strings.TrimSpace(sel)
+}
+
+func (p SelectionPolicy) dedupe() bool { return p&(DedupeKeepFirst|DedupeKeepLast) != 0 }
+
+func (p SelectionPolicy) validate(allowDuplicates bool) error {
+	var errs []error
+	if p&DedupeKeepFirst != 0 && p&DedupeKeepLast != 0 {
+		errs = append(errs, errors.New("the combination of the DedupeKeepFirst and DedupeKeepLast policies is ambiguous."))
+	}
+	if p.dedupe() && allowDuplicates {
+		errs = append(errs, errors.New("the combination of a deduplication policy and the allowDuplicates option is ambiguous."))
+	}
+	return errors.Join(errs...)
+}
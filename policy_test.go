@@ -0,0 +1,131 @@
+package godmenu
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSelectionPolicy(t *testing.T) {
+	t.Run("Normalize", func(t *testing.T) {
+		for _, tc := range []struct {
+			policy SelectionPolicy
+			in     string
+			out    string
+		}{
+			{0, "  one two  ", "one two"},
+			{0, "one  two", "one  two"},
+			{CollapseWhitespace, " one \t two\n three ", "one two three"},
+			{StripControl, "one\ttwo\x1b[0m\x00", "one two[0m"},
+			{StripControl, "one\r\ntwo", "one  two"},
+			{StripControl | CollapseWhitespace, "one\r\n\x07two", "one two"},
+			{DropBlanks, " \t ", ""},
+		} {
+			if got := tc.policy.normalize(tc.in); got != tc.out {
+				t.Errorf("%d: %q became %q, expected %q", tc.policy, tc.in, got, tc.out)
+			}
+		}
+	})
+	t.Run("Default", func(t *testing.T) {
+		for name, sels := range map[string][]string{
+			"Blank":     {"one", " ", "two"},
+			"Duplicate": {"one", "two", "one"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Run(t.Context(), WithBackend(&mockBackend{output: "one"}), Items(sels...))
+				if !errors.Is(err, ErrConfigurationInvalid) {
+					t.Error(err)
+				}
+			})
+		}
+	})
+	t.Run("DropBlanks", func(t *testing.T) {
+		mock := &mockBackend{output: "two"}
+		res, err := RunResult(t.Context(), WithBackend(mock), Items("one", "", " ", "two"), SetSelectionPolicy(DropBlanks))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "one\ntwo" {
+			t.Errorf("%q", mock.input)
+		}
+		if res.Value != "two" || res.Index != 3 {
+			t.Errorf("%+v", res)
+		}
+
+		st := (&set{}).withPolicy(DropBlanks).init([]string{"", "one", "\n"})
+		if st.Len() != 1 || len(st.items) != 1 || string(st.rendered(false)) != "one" {
+			t.Error(st.Len(), st.items)
+		}
+	})
+	t.Run("OnlyBlanks", func(t *testing.T) {
+		_, err := Run(t.Context(), WithBackend(&mockBackend{}), Items("", " "), SetSelectionPolicy(DropBlanks))
+		if !errors.Is(err, ErrConfigurationInvalid) {
+			t.Error(err)
+		}
+	})
+	t.Run("KeepFirst", func(t *testing.T) {
+		mock := &mockBackend{output: "a"}
+		res, err := RunResult(t.Context(), WithBackend(mock), Items("a", "b", "a", "c", "b"), SetSelectionPolicy(DedupeKeepFirst))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "a\nb\nc" || res.Index != 0 {
+			t.Errorf("%q %+v", mock.input, res)
+		}
+	})
+	t.Run("KeepLast", func(t *testing.T) {
+		mock := &mockBackend{output: "a"}
+		res, err := RunResult(t.Context(), WithBackend(mock), Items("a", "b", "a", "c", "b"), SetSelectionPolicy(DedupeKeepLast))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "a\nc\nb" || res.Index != 2 {
+			t.Errorf("%q %+v", mock.input, res)
+		}
+	})
+	t.Run("Combined", func(t *testing.T) {
+		mock := &mockBackend{output: "two words"}
+		out, err := Select(t.Context(), []string{"one", "two\twords", "", "two  words"}, func(s string) string { return s },
+			SetSelectionPolicy(CollapseWhitespace|StripControl|DropBlanks), WithSelectionPolicy(DedupeKeepLast), WithBackend(mock))
+		if err != nil || out != "two  words" {
+			t.Error(out, err)
+		}
+		if mock.input != "one\ntwo words" {
+			t.Errorf("%q", mock.input)
+		}
+	})
+	t.Run("Items", func(t *testing.T) {
+		mock := &mockBackend{output: "Emacs"}
+		out, err := Run(t.Context(), WithBackend(mock),
+			WithItems(MakeItem("", "blank"), MakeItem("Emacs", "emacs"), MakeItem("Vim", "vim"), MakeItem("Emacs", "emacs")),
+			SetSelectionPolicy(DropBlanks|DedupeKeepFirst))
+		if err != nil || out != "emacs" {
+			t.Error(out, err)
+		}
+		if mock.input != "Emacs\nVim" {
+			t.Errorf("%q", mock.input)
+		}
+	})
+	t.Run("CallerSlice", func(t *testing.T) {
+		sels := []string{" one ", "two\n"}
+		if _, err := Run(t.Context(), WithBackend(&mockBackend{output: "one"}), SetSelections(sels)); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(sels, []string{" one ", "two\n"}) {
+			t.Errorf("%q", sels)
+		}
+	})
+	t.Run("Ambiguous", func(t *testing.T) {
+		for name, args := range map[string][]Arg{
+			"FirstAndLast":   {SetSelectionPolicy(DedupeKeepFirst | DedupeKeepLast)},
+			"DedupeAndAllow": {SetSelectionPolicy(DedupeKeepFirst), AllowDuplicateSelections()},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Run(t.Context(), append(args, WithBackend(&mockBackend{output: "a"}), Items("a", "b"))...)
+				if !errors.Is(err, ErrConfigurationInvalid) {
+					t.Error(err)
+				}
+			})
+		}
+	})
+}
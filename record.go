@@ -0,0 +1,225 @@
+package godmenu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Recording describes one invocation of a launcher, as written by a
+// Recorder and served by a Replay. Recordings are stored as JSON, one
+// per line.
+type Recording struct {
+	Program  string   `json:"program"`
+	Args     []string `json:"args"`
+	Stdin    string   `json:"stdin"`
+	Stdout   string   `json:"stdout"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code"`
+	// Error holds errors which do not report an exit code, for
+	// instance when the program could not start.
+	Error string `json:"error,omitempty"`
+}
+
+// Recorder is a runner which runs launchers with another runner,
+// and writes a Recording of each invocation.
+type Recorder struct {
+	runner Runner
+	mu     sync.Mutex
+	out    io.Writer
+}
+
+// NewRecorder returns a runner which records invocations of the
+// runner (or, when nil, ExecRunner) to w.
+func NewRecorder(r Runner, w io.Writer) *Recorder {
+	if r == nil {
+		r = ExecRunner{}
+	}
+	return &Recorder{runner: r, out: w}
+}
+
+func (r *Recorder) LookPath(program string) (string, error) { return r.runner.LookPath(program) }
+
+// Run runs the command, and records the invocation. The input is
+// recorded as the launcher reads it, so that streamed selections
+// which never end can be recorded, and includes only what was read
+// before the launcher exited. When the recording cannot be written,
+// Run returns the error.
+func (r *Recorder) Run(ctx context.Context, cmd Command) ([]byte, []byte, error) {
+	var stdin lockedBuffer
+	if cmd.Stdin != nil {
+		cmd.Stdin = io.TeeReader(cmd.Stdin, &stdin)
+	}
+
+	stdout, stderr, err := r.runner.Run(ctx, cmd)
+
+	rec := Recording{
+		Program: cmd.Program,
+		Args:    cmd.Args,
+		Stdin:   stdin.String(),
+		Stdout:  string(stdout),
+		Stderr:  string(stderr),
+	}
+	if code, ok := exitCode(err); ok {
+		rec.ExitCode = code
+	} else if err != nil {
+		rec.Error = err.Error()
+	}
+
+	if werr := r.write(rec); werr != nil {
+		return stdout, stderr, errors.Join(err, fmt.Errorf("recording %s: %w", cmd.Program, werr))
+	}
+
+	return stdout, stderr, err
+}
+
+func (r *Recorder) write(rec Recording) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.out.Write(append(data, '\n'))
+	return err
+}
+
+// lockedBuffer holds the input that a launcher read, which runners
+// may copy to the launcher in another goroutine.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// Replay is a runner which, rather than running launchers, serves
+// recorded responses in order. Calls whose program, arguments or
+// input differ from the recording fail with ErrReplayMismatch, as do
+// calls after the end of the recording. As the recording holds the
+// input that the launcher read, only that much of the input is read
+// and compared.
+type Replay struct {
+	mu      sync.Mutex
+	records []Recording
+	next    int
+}
+
+// NewReplay reads recordings, as written by a Recorder.
+func NewReplay(r io.Reader) (*Replay, error) {
+	rp := &Replay{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var rec Recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("reading recording on line %d: %w", line, err)
+		}
+		rp.records = append(rp.records, rec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rp, nil
+}
+
+// LookPath accepts every program, as replays do not run launchers.
+func (*Replay) LookPath(program string) (string, error) { return program, nil }
+
+func (rp *Replay) Run(ctx context.Context, cmd Command) ([]byte, []byte, error) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.next >= len(rp.records) {
+		return nil, nil, fmt.Errorf("call %d of %s is not in the recording of %d calls: %w", rp.next+1, cmd.Program, len(rp.records), ErrReplayMismatch)
+	}
+
+	rec := rp.records[rp.next]
+	switch {
+	case rec.Program != cmd.Program:
+		return nil, nil, fmt.Errorf("call %d ran %q, but the recording ran %q: %w", rp.next+1, cmd.Program, rec.Program, ErrReplayMismatch)
+	case !slices.Equal(rec.Args, cmd.Args) && (len(rec.Args) != 0 || len(cmd.Args) != 0):
+		return nil, nil, fmt.Errorf("call %d passed %q, but the recording passed %q: %w", rp.next+1, cmd.Args, rec.Args, ErrReplayMismatch)
+	}
+
+	// streams may never end, so no more is read than the
+	// recording holds.
+	stdin, err := readInput(ctx, cmd.Stdin, len(rec.Stdin))
+	if err != nil {
+		return nil, nil, err
+	}
+	if rec.Stdin != string(stdin) {
+		return nil, nil, fmt.Errorf("call %d presented %q, but the recording presented %q: %w", rp.next+1, stdin, rec.Stdin, ErrReplayMismatch)
+	}
+	rp.next++
+
+	switch {
+	case rec.Error != "":
+		err = errors.New(rec.Error)
+	case rec.ExitCode != 0:
+		err = exitStatus(rec.ExitCode)
+	}
+
+	return []byte(rec.Stdout), []byte(rec.Stderr), err
+}
+
+// readInput reads up to n bytes of the input, or less when the input
+// ends first, unless the context ends before they are read.
+func readInput(ctx context.Context, r io.Reader, n int) ([]byte, error) {
+	if r == nil || n == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, n)
+	done := make(chan int, 1)
+	go func() {
+		read, _ := io.ReadFull(r, buf)
+		done <- read
+	}()
+
+	select {
+	case read := <-done:
+		return buf[:read], nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Remaining reports the number of recorded calls which have not been
+// replayed.
+func (rp *Replay) Remaining() int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return len(rp.records) - rp.next
+}
+
+// exitStatus is the error for a replayed launcher which exited
+// unsuccessfully.
+type exitStatus int
+
+func (e exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(e)) }
+func (e exitStatus) ExitCode() int { return int(e) }
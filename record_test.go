@@ -0,0 +1,205 @@
+package godmenu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	var session bytes.Buffer
+
+	// a two menu walkthrough, in which the user picks an editor
+	// and then dismisses the second menu.
+	walkthrough := func(runner Runner) (string, error) {
+		editor, err := Run(t.Context(), WithRunner(runner), Items("vim", "emacs"), MenuPrompt("editor"))
+		if err != nil {
+			return "", err
+		}
+		_, err = Run(t.Context(), WithRunner(runner), WithBackend(Rofi()), Items("open", "close"), MenuPrompt(editor))
+		return editor, err
+	}
+
+	t.Run("Record", func(t *testing.T) {
+		runner := &sequenceRunner{responses: []mockRunner{{stdout: "emacs\n"}, {err: exitStatus(1)}}}
+
+		editor, err := walkthrough(NewRecorder(runner, &session))
+		if editor != "emacs" || !errors.Is(err, ErrCanceled) {
+			t.Fatal(editor, err)
+		}
+		if lines := strings.Count(session.String(), "\n"); lines != 2 {
+			t.Fatal(session.String())
+		}
+	})
+	t.Run("Replay", func(t *testing.T) {
+		replay, err := NewReplay(bytes.NewReader(session.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		editor, err := walkthrough(replay)
+		if editor != "emacs" || !errors.Is(err, ErrCanceled) {
+			t.Error(editor, err)
+		}
+		if replay.Remaining() != 0 {
+			t.Error(replay.Remaining())
+		}
+
+		_, err = Run(t.Context(), WithRunner(replay), Items("vim"))
+		if !errors.Is(err, ErrReplayMismatch) {
+			t.Error(err)
+		}
+	})
+	t.Run("Mismatch", func(t *testing.T) {
+		for name, args := range map[string][]Arg{
+			"Selections": {Items("vim", "nano")},
+			"Arguments":  {Items("vim", "emacs"), MenuPrompt("other")},
+			"Program":    {Items("vim", "emacs"), MenuPrompt("editor"), DMenuPath("/opt/dmenu")},
+		} {
+			t.Run(name, func(t *testing.T) {
+				replay, err := NewReplay(bytes.NewReader(session.Bytes()))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_, err = Run(t.Context(), append(args, WithRunner(replay))...)
+				if !errors.Is(err, ErrReplayMismatch) {
+					t.Error(err)
+				}
+				if replay.Remaining() != 2 {
+					t.Error(replay.Remaining())
+				}
+			})
+		}
+	})
+	t.Run("Failure", func(t *testing.T) {
+		var session bytes.Buffer
+		runner := &mockRunner{stderr: "cannot open display", err: exitStatus(2)}
+
+		if _, err := Run(t.Context(), WithRunner(NewRecorder(runner, &session)), Items("one")); !errors.Is(err, ErrDmenuFailure) {
+			t.Fatal(err)
+		}
+
+		replay, err := NewReplay(&session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Run(t.Context(), WithRunner(replay), Items("one"))
+		var derr *DmenuError
+		if !errors.As(err, &derr) || derr.ExitCode != 2 || derr.Stderr != "cannot open display" {
+			t.Error(err)
+		}
+	})
+	t.Run("Stream", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("requires a posix shell")
+		}
+		path := filepath.Join(t.TempDir(), "launcher")
+		if err := os.WriteFile(path, []byte("#!/bin/sh\nread -r first\nread -r second\necho \"$second\"\n"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		// the stream never ends, so the launcher's input is
+		// recorded as it reads it.
+		ch := make(chan string, 2)
+		ch <- "one"
+		ch <- "two"
+
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		t.Cleanup(cancel)
+
+		var session bytes.Buffer
+		out, err := Run(ctx, WithRunner(NewRecorder(nil, &session)), DMenuPath(path), Items("zero"), FromChannel(ch))
+		if err != nil || out != "one" {
+			t.Fatal(out, err)
+		}
+		if !strings.Contains(session.String(), `"stdin":"zero\none`) {
+			t.Error(session.String())
+		}
+
+		replay, err := NewReplay(&session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ch = make(chan string, 2)
+		ch <- "one"
+		ch <- "two"
+		out, err = Run(ctx, WithRunner(replay), DMenuPath(path), Items("zero"), FromChannel(ch))
+		if err != nil || out != "one" || replay.Remaining() != 0 {
+			t.Error(out, err)
+		}
+	})
+	t.Run("PartialInput", func(t *testing.T) {
+		items := make([]string, 100_000)
+		for idx := range items {
+			items[idx] = strconv.Itoa(idx)
+		}
+
+		var session bytes.Buffer
+		runner := &partialRunner{read: 2, stdout: "0\n"}
+		if out, err := Run(t.Context(), WithRunner(NewRecorder(runner, &session)), SetSelections(items)); err != nil || out != "0" {
+			t.Fatal(out, err)
+		}
+		if !strings.Contains(session.String(), `"stdin":"0\n"`) {
+			t.Error(session.String())
+		}
+
+		replay, err := NewReplay(&session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, err := Run(t.Context(), WithRunner(replay), SetSelections(items)); err != nil || out != "0" {
+			t.Error(out, err)
+		}
+
+		replay, err = NewReplay(strings.NewReader(session.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Run(t.Context(), WithRunner(replay), Items("1", "0")); !errors.Is(err, ErrReplayMismatch) {
+			t.Error(err)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := NewReplay(strings.NewReader("{}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Error(err)
+		}
+	})
+}
+
+// sequenceRunner serves the responses of mock runners in order.
+type sequenceRunner struct {
+	responses []mockRunner
+}
+
+func (s *sequenceRunner) LookPath(program string) (string, error) { return program, nil }
+
+func (s *sequenceRunner) Run(ctx context.Context, cmd Command) ([]byte, []byte, error) {
+	next := &s.responses[0]
+	s.responses = s.responses[1:]
+	return next.Run(ctx, cmd)
+}
+
+// partialRunner reads only the start of the input, as launchers which
+// exit before reading all of the selections do.
+type partialRunner struct {
+	read   int
+	stdout string
+}
+
+func (partialRunner) LookPath(program string) (string, error) { return program, nil }
+
+func (p *partialRunner) Run(_ context.Context, cmd Command) ([]byte, []byte, error) {
+	if _, err := io.ReadFull(cmd.Stdin, make([]byte, p.read)); err != nil {
+		return nil, nil, err
+	}
+	return []byte(p.stdout), nil, nil
+}
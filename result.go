@@ -0,0 +1,40 @@
+package godmenu
+
+import "time"
+
+// Result describes the outcome of a menu.
+type Result struct {
+	// Value is the selection: the selected item's value, or the
+	// text the user entered.
+	Value string
+	// Index is the position of the selection in the Selections
+	// followed by the Items, the items of the Sources, and any
+	// streamed selections, and is -1 when the selection is not
+	// one of them.
+	Index int
+	// Matched reports if the selection is one of the Selections or
+	// Items, rather than text the user entered, or a substitution
+	// they confirmed.
+	Matched bool
+	// Query is the text the user entered. Backends which report
+	// it separately from the selection (e.g. fzf, with
+	// PrintQuery) always provide it; otherwise it is only known
+	// when the selection did not match.
+	Query string
+	// Key is the key the user pressed to accept the selection,
+	// for backends which report it (e.g. rofi's custom
+	// keybindings, or fzf's Expect keys).
+	Key string
+	// ExitCode is the exit status of the launcher, which is
+	// non-zero for launchers that report custom keybindings or
+	// cancellation in their exit status.
+	ExitCode int
+	// Duration is the time the menu (and any confirmation menu)
+	// was open.
+	Duration time.Duration
+	// Backend is the name of the backend that ran the menu.
+	Backend string
+	// Source is the name of the Source of the selected item, and
+	// is empty for selections which did not come from a source.
+	Source string
+}
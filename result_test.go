@@ -0,0 +1,105 @@
+package godmenu
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	t.Run("Matched", func(t *testing.T) {
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{output: "Emacs"}), Items("vim"), WithItems(MakeItem("Emacs", "emacs")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "emacs" || res.Index != 1 || !res.Matched || res.Query != "" || res.Backend != "mock" {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("FreeText", func(t *testing.T) {
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{output: " nano\n"}), Items("vim", "emacs"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "nano" || res.Index != -1 || res.Matched || res.Query != "nano" {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("Transform", func(t *testing.T) {
+		res, err := RunResult(t.Context(),
+			WithBackend(&mockBackend{output: "VIM"}),
+			Items("vim", "emacs"),
+			func(o *Options) { o.Transform = strings.ToLower },
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "vim" || res.Index != 0 || !res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("Launcher", func(t *testing.T) {
+		path, _ := standIn(t, "two\n", 0)
+
+		res, err := RunResult(t.Context(), DMenuPath(path), Items("one", "two"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "two" || res.Index != 1 || res.ExitCode != 0 || res.Backend != "dmenu" || res.Duration <= 0 {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("CustomKeybinding", func(t *testing.T) {
+		path, _ := standIn(t, "one\n", 11)
+
+		res, err := RunResult(t.Context(), WithBackend(Rofi()), DMenuPath(path), Items("one", "two"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "one" || res.ExitCode != 11 || res.Key != "kb-custom-2" || res.Backend != "rofi" {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("Query", func(t *testing.T) {
+		path, _ := standIn(t, "on\none\n", 0)
+
+		res, err := RunResult(t.Context(), WithBackend(Fzf{PrintQuery: true}), DMenuPath(path), Items("one", "two"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "one" || res.Query != "on" || !res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("Failure", func(t *testing.T) {
+		path, _ := standIn(t, "", 1)
+
+		res, err := RunResult(t.Context(), WithBackend(Rofi()), DMenuPath(path), Items("one", "two"))
+		if !errors.Is(err, ErrSelectionMissing) {
+			t.Fatal(err)
+		}
+		if res == nil || res.ExitCode != 1 || res.Value != "" || res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{}))
+		if !errors.Is(err, ErrConfigurationInvalid) || res != nil {
+			t.Error(res, err)
+		}
+	})
+	t.Run("Confirmed", func(t *testing.T) {
+		res, err := RunResult(t.Context(),
+			WithBackend(&mockBackend{output: "vim"}),
+			Items("vim", "emacs"),
+			func(o *Options) { o.Transform = func(s string) string { return s + " -R" } },
+			ConfirmSubstituion(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Value != "vim" || res.Matched || res.Index != -1 {
+			t.Errorf("%+v", res)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package godmenu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rofi returns a backend which runs rofi in dmenu mode. Colors and
+// the menu position are passed to rofi as a theme string, and the
+// font is converted to Pango's format. Rofi reports the position of
+// the selection, so duplicate selections are shown as they are.
+func Rofi() Backend { return rofiBackend }
+
+var rofiBackend = &launcher{name: "rofi", program: DefaultRofiPath, args: rofiArgs, parse: rofiParse, multi: true, indexes: true}
+
+// rofiFormat has rofi print the index of each selection (or -1 for
+// text that the user entered) before the selection.
+const rofiFormat = "i s"
+
+func rofiArgs(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 20)
+	args = append(args, "-dmenu", "-format", rofiFormat)
+
+	if opts.Multi {
+		args = append(args, "-multi-select")
+	}
+
+	if conf.CaseSensitive {
+		args = append(args, "-case-sensitive")
+	} else {
+		args = append(args, "-i")
+	}
+
+	if conf.Lines > 0 {
+		args = append(args, "-l", fmt.Sprint(conf.Lines))
+	}
+
+	if conf.Font != "" {
+		args = append(args, "-font", pangoFont(conf.Font))
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "-p", conf.Prompt)
+	}
+
+	if conf.Monitor != nil {
+		args = append(args, "-m", fmt.Sprint(*conf.Monitor))
+	}
+
+	if conf.WindowID != nil {
+		args = append(args, "-w", fmt.Sprint(*conf.WindowID))
+	}
+
+	if theme := rofiTheme(conf); theme != "" {
+		args = append(args, "-theme-str", theme)
+	}
+
+	return args
+}
+
+// rofiTheme renders the colors and position from the flags as a
+// rofi theme fragment.
+func rofiTheme(conf *Flags) string {
+	var rules []string
+
+	if props := rofiColors(conf.BackgroundColor, conf.TextColor); props != "" {
+		rules = append(rules, fmt.Sprintf("* { %s }", props))
+	}
+
+	if props := rofiColors(conf.SelectedBgColor, conf.SelectedTextColor); props != "" {
+		rules = append(rules,
+			fmt.Sprintf("element selected.normal { %s }", props),
+			"element-text { background-color: inherit; text-color: inherit; }",
+		)
+	}
+
+	if conf.Bottom {
+		rules = append(rules, "window { location: south; anchor: south; }")
+	}
+
+	return strings.Join(rules, " ")
+}
+
+func rofiColors(bg, fg string) string {
+	var props []string
+	if bg != "" {
+		props = append(props, fmt.Sprintf("background-color: %s;", bg))
+	}
+	if fg != "" {
+		props = append(props, fmt.Sprintf("text-color: %s;", fg))
+	}
+	return strings.Join(props, " ")
+}
+
+// rofiParse interprets rofi's exit codes: 1 means the user dismissed
+// the menu, and 10 through 28 mean that the selection was accepted
+// using one of the custom keybindings (kb-custom-1 to kb-custom-19),
+// which is reported as the response's Key.
+func rofiParse(out []byte, err error) (Response, error) {
+	code, ok := exitCode(err)
+	switch {
+	case err == nil:
+		return rofiIndexes(out), nil
+	case ok && code == 1:
+		return Response{}, canceled("rofi", err)
+	case ok && code >= 10 && code <= 28:
+		resp := rofiIndexes(out)
+		resp.Key = fmt.Sprintf("kb-custom-%d", code-9)
+		return resp, nil
+	default:
+		return Response{}, launchError("rofi", err)
+	}
+}
+
+// rofiIndexes splits the index from each line of output, as printed
+// with rofiFormat. Lines without an index are kept as they are.
+func rofiIndexes(out []byte) Response {
+	var resp Response
+	var lines []string
+	for line := range strings.Lines(string(out)) {
+		line = strings.TrimRight(line, "\n")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		idx := -1
+		if prefix, rest, ok := strings.Cut(line, " "); ok {
+			if n, err := strconv.Atoi(prefix); err == nil {
+				idx, line = n, rest
+			}
+		}
+		resp.Indexes = append(resp.Indexes, idx)
+		lines = append(lines, line)
+	}
+
+	if len(lines) > 0 {
+		resp.Output = []byte(strings.Join(lines, "\n") + "\n")
+	}
+	return resp
+}
@@ -0,0 +1,103 @@
+package godmenu
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRofi(t *testing.T) {
+	t.Run("Arguments", func(t *testing.T) {
+		path, record := standIn(t, "two\n", 0)
+
+		out, err := Run(t.Context(),
+			WithBackend(Rofi()),
+			DMenuPath(path),
+			Items("one", "two"),
+			MenuPrompt("pick"),
+			MenuLines(5),
+			MenuBottom(),
+			MenuMonitor(1),
+		)
+		if err != nil || out != "two" {
+			t.Fatal(out, err)
+		}
+
+		args, stdin := record()
+		if stdin != "one\ntwo" {
+			t.Errorf("%q", stdin)
+		}
+
+		conf := DefaultFlags()
+		conf.Prompt = "pick"
+		conf.Lines = 5
+		conf.Bottom = true
+		expected := []string{
+			"-dmenu",
+			"-format", "i s",
+			"-i",
+			"-l", "5",
+			"-font", "Source Code Pro 13",
+			"-p", "pick",
+			"-m", "1",
+			"-theme-str", rofiTheme(conf),
+		}
+		if !slices.Equal(args, expected) {
+			t.Errorf("got %q\nexpected %q", args, expected)
+		}
+	})
+	t.Run("Theme", func(t *testing.T) {
+		theme := rofiTheme(&Flags{BackgroundColor: "#000", TextColor: "white", Bottom: true})
+		expected := "* { background-color: #000; text-color: white; } window { location: south; anchor: south; }"
+		if theme != expected {
+			t.Errorf("%q", theme)
+		}
+		if theme := rofiTheme(&Flags{}); theme != "" {
+			t.Errorf("%q", theme)
+		}
+	})
+	t.Run("CaseSensitive", func(t *testing.T) {
+		args := rofiArgs(ResolveOptions(CaseSensitive()))
+		if slices.Contains(args, "-i") || !slices.Contains(args, "-case-sensitive") {
+			t.Error(args)
+		}
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		path, _ := standIn(t, "", 1)
+
+		out, err := Run(t.Context(), WithBackend(Rofi()), DMenuPath(path), Items("one", "two"))
+		if !errors.Is(err, ErrSelectionMissing) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("CustomKeybinding", func(t *testing.T) {
+		path, _ := standIn(t, "one", 12)
+
+		out, err := Run(t.Context(), WithBackend(Rofi()), DMenuPath(path), Items("one", "two"))
+		if err != nil || out != "one" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Failure", func(t *testing.T) {
+		path, _ := standIn(t, "could not open display", 2)
+
+		out, err := Run(t.Context(), WithBackend(Rofi()), DMenuPath(path), Items("one", "two"))
+		if err == nil || errors.Is(err, ErrSelectionMissing) || out != "" {
+			t.Error(out, err)
+		}
+	})
+}
+
+func TestFonts(t *testing.T) {
+	for input, expected := range map[string]string{
+		"Source Code Pro-13":             "Source Code Pro 13",
+		"monospace:size=10":              "monospace 10",
+		"Fira Code:style=Bold:size=11.5": "Fira Code 11.5",
+		"Noto Sans Mono-CJK":             "Noto Sans Mono-CJK",
+		"monospace":                      "monospace",
+	} {
+		if out := pangoFont(input); out != expected {
+			t.Errorf("%q => %q, expected %q", input, out, expected)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package godmenu
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+)
+
+// Runner finds and runs the programs of the launcher backends. The
+// default runner, ExecRunner, runs them as subprocesses. Applications
+// may provide their own runner (with WithRunner) to substitute fakes,
+// add sandboxing, or run launchers through another program.
+type Runner interface {
+	// LookPath resolves the program, as exec.LookPath, to check
+	// that it is available.
+	LookPath(program string) (string, error)
+	// Run runs the command until it exits, returning what it
+	// wrote to standard output and, unless the command's Stderr
+	// is set, to standard error. Errors
+	// from commands which ran but failed should wrap an
+	// *exec.ExitError, or another error with an ExitCode() int
+	// method, which describes how it exited.
+	Run(ctx context.Context, cmd Command) (stdout, stderr []byte, err error)
+}
+
+// Command describes one invocation of a launcher.
+type Command struct {
+	// Program is the name or path of the program.
+	Program string
+	// Args are the program's arguments, not including its name.
+	Args []string
+	// Stdin holds the rendered selections.
+	Stdin io.Reader
+	// Env, when not nil, holds the environment variables ("key=value")
+	// added to the environment of the current process.
+	Env []string
+	// Dir, when set, is the working directory of the program.
+	Dir string
+	// Stderr, when set, receives the program's standard error in
+	// place of the runner capturing it: terminal launchers (e.g.
+	// fzf) draw their interface there.
+	Stderr io.Writer
+}
+
+// ExecRunner runs programs as subprocesses, using os/exec.
+type ExecRunner struct{}
+
+func (ExecRunner) LookPath(program string) (string, error) { return exec.LookPath(program) }
+
+func (ExecRunner) Run(ctx context.Context, c Command) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, c.Program, c.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if c.Stderr != nil {
+		cmd.Stderr = c.Stderr
+	}
+	cmd.Dir = c.Dir
+	if c.Env != nil {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
+	// the input may be streamed, and so may not end before the
+	// launcher does: copy it into a pipe, which Wait closes when
+	// the launcher exits, rather than having Wait wait for it.
+	var stdin io.WriteCloser
+	if c.Stdin != nil {
+		var err error
+		if stdin, err = cmd.StdinPipe(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+	if stdin != nil {
+		go func() {
+			_, _ = io.Copy(stdin, c.Stdin)
+			_ = stdin.Close()
+		}()
+	}
+
+	err := cmd.Wait()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// WrapRunner returns a runner which runs launchers through another
+// program, such as env, firejail or systemd-run: the launcher and its
+// arguments follow the prefix. Both the wrapper and the launcher must
+// be available.
+func WrapRunner(r Runner, prefix ...string) Runner {
+	if len(prefix) == 0 {
+		return r
+	}
+	return wrapRunner{runner: r, prefix: prefix}
+}
+
+type wrapRunner struct {
+	runner Runner
+	prefix []string
+}
+
+func (w wrapRunner) LookPath(program string) (string, error) {
+	if _, err := w.runner.LookPath(w.prefix[0]); err != nil {
+		return "", err
+	}
+	return w.runner.LookPath(program)
+}
+
+func (w wrapRunner) Run(ctx context.Context, c Command) ([]byte, []byte, error) {
+	c.Args = slices.Concat(w.prefix[1:], []string{c.Program}, c.Args)
+	c.Program = w.prefix[0]
+	return w.runner.Run(ctx, c)
+}
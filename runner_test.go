@@ -0,0 +1,134 @@
+package godmenu
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type mockRunner struct {
+	missing error
+	stdout  string
+	stderr  string
+	err     error
+	// unread, when set, returns without reading the input.
+	unread bool
+
+	lookups []string
+	cmd     Command
+	stdin   string
+}
+
+func (m *mockRunner) LookPath(program string) (string, error) {
+	m.lookups = append(m.lookups, program)
+	return program, m.missing
+}
+
+func (m *mockRunner) Run(_ context.Context, cmd Command) ([]byte, []byte, error) {
+	if m.unread {
+		m.cmd = cmd
+		return []byte(m.stdout), []byte(m.stderr), m.err
+	}
+	data, err := io.ReadAll(cmd.Stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.cmd, m.stdin = cmd, string(data)
+	return []byte(m.stdout), []byte(m.stderr), m.err
+}
+
+func TestRunner(t *testing.T) {
+	t.Run("Run", func(t *testing.T) {
+		runner := &mockRunner{stdout: "two\n"}
+
+		out, err := Run(t.Context(), WithRunner(runner), WithBackend(Bemenu()), Items("one", "two"),
+			MenuPrompt("go"), WithEnv("BEMENU_BACKEND=curses"), WorkingDir("/tmp"))
+		if err != nil || out != "two" {
+			t.Fatal(out, err)
+		}
+
+		if !slices.Equal(runner.lookups, []string{"bemenu"}) {
+			t.Error(runner.lookups)
+		}
+		if runner.cmd.Program != "bemenu" || !slices.Contains(runner.cmd.Args, "go") {
+			t.Errorf("%+v", runner.cmd)
+		}
+		if !slices.Equal(runner.cmd.Env, []string{"BEMENU_BACKEND=curses"}) || runner.cmd.Dir != "/tmp" {
+			t.Errorf("%+v", runner.cmd)
+		}
+		if runner.stdin != "one\ntwo" {
+			t.Errorf("%q", runner.stdin)
+		}
+	})
+	t.Run("Missing", func(t *testing.T) {
+		runner := &mockRunner{missing: exec.ErrNotFound}
+
+		_, err := Run(t.Context(), WithRunner(runner), Items("one", "two"))
+		if !errors.Is(err, ErrConfigurationInvalid) || !errors.Is(err, exec.ErrNotFound) {
+			t.Error(err)
+		}
+		if runner.cmd.Program != "" {
+			t.Error("ran a missing launcher", runner.cmd)
+		}
+	})
+	t.Run("Failure", func(t *testing.T) {
+		runner := &mockRunner{stderr: "sandbox denied", err: errors.New("boom")}
+
+		_, err := Run(t.Context(), WithRunner(runner), Items("one", "two"))
+		var derr *DmenuError
+		if !errors.As(err, &derr) || derr.Stderr != "sandbox denied" || derr.ExitCode != -1 {
+			t.Error(err)
+		}
+	})
+	t.Run("Wrap", func(t *testing.T) {
+		runner := &mockRunner{stdout: "one\n"}
+
+		out, err := Run(t.Context(), WithRunner(WrapRunner(runner, "systemd-run", "--user", "--pipe")), Items("one"), MenuPrompt("go"))
+		if err != nil || out != "one" {
+			t.Fatal(out, err)
+		}
+		if !slices.Equal(runner.lookups, []string{"systemd-run", "dmenu"}) {
+			t.Error(runner.lookups)
+		}
+		if runner.cmd.Program != "systemd-run" || !slices.Equal(runner.cmd.Args[:4], []string{"--user", "--pipe", "dmenu", "-i"}) {
+			t.Errorf("%+v", runner.cmd)
+		}
+		if WrapRunner(runner) != Runner(runner) {
+			t.Error("wrapping without a prefix should return the runner")
+		}
+	})
+	t.Run("Exec", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("requires a posix shell")
+		}
+		dir := t.TempDir()
+
+		stdout, stderr, err := ExecRunner{}.Run(t.Context(), Command{
+			Program: "sh",
+			Args:    []string{"-c", `cat; echo " $GODMENU_TEST $(pwd)"; echo warning >&2`},
+			Stdin:   strings.NewReader("input"),
+			Env:     []string{"GODMENU_TEST=set"},
+			Dir:     dir,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(string(stdout)); got != "input set "+dir && got != "input set "+filepath.Clean(dir) {
+			t.Errorf("%q", got)
+		}
+		if string(stderr) != "warning\n" {
+			t.Errorf("%q", stderr)
+		}
+
+		var terminal strings.Builder
+		_, stderr, err = ExecRunner{}.Run(t.Context(), Command{Program: "sh", Args: []string{"-c", "echo drawn >&2"}, Stderr: &terminal})
+		if err != nil || len(stderr) != 0 || terminal.String() != "drawn\n" {
+			t.Errorf("%q %q %v", stderr, terminal.String(), err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package godmenu
+
+import (
+	"context"
+	"fmt"
+)
+
+// Select presents the items, each shown using label, and returns the
+// item that the user chose. Labels must be unique. Because only the
+// items can be returned, selecting anything else, including text
+// which the user entered when RequireMatch is not set, is an
+// ErrSelectionUnknown error. Selections from the arguments are shown
+// before the items, and selecting one is also an error.
+func Select[T any](ctx context.Context, items []T, label func(T) string, args ...Arg) (T, error) {
+	var zero T
+
+	opts := newop().apply(args)
+	offset := len(opts.Selections) + len(opts.Items)
+	labeled := make([]Item, len(items))
+	for idx, item := range items {
+		labeled[idx] = Item{Label: label(item)}
+	}
+	opts.Items = extend(opts.Items, labeled...)
+
+	res, err := DoResult(ctx, *opts)
+	switch {
+	case err != nil:
+		return zero, err
+	case res.Index < offset || res.Index >= offset+len(items):
+		return zero, fmt.Errorf("%q is not one of the items: %w", res.Value, ErrSelectionUnknown)
+	default:
+		return items[res.Index-offset], nil
+	}
+}
+
+// SelectStringer calls Select, labeling the items using their String
+// methods.
+func SelectStringer[T fmt.Stringer](ctx context.Context, items []T, args ...Arg) (T, error) {
+	return Select(ctx, items, T.String, args...)
+}
@@ -0,0 +1,93 @@
+package godmenu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testColor struct {
+	name string
+	hex  string
+}
+
+func (c testColor) String() string { return fmt.Sprintf("%s (%s)", c.name, c.hex) }
+
+func TestSelect(t *testing.T) {
+	colors := []testColor{{"black", "#000000"}, {"white", "#ffffff"}, {"blue", "#005577"}}
+	name := func(c testColor) string { return c.name }
+
+	t.Run("Label", func(t *testing.T) {
+		mb := &mockBackend{output: "blue"}
+		out, err := Select(t.Context(), colors, name, WithBackend(mb), Sorted())
+		if err != nil || out != colors[2] {
+			t.Error(out, err)
+		}
+		if mb.input != "black\nblue\nwhite" {
+			t.Errorf("%q", mb.input)
+		}
+	})
+	t.Run("Stringer", func(t *testing.T) {
+		mb := &mockBackend{output: "white (#ffffff)"}
+		out, err := SelectStringer(t.Context(), colors, WithBackend(mb))
+		if err != nil || out != colors[1] {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Pointers", func(t *testing.T) {
+		ptrs := []*testColor{&colors[0], &colors[1]}
+		out, err := SelectStringer(t.Context(), ptrs, WithBackend(&mockBackend{output: "black (#000000)"}))
+		if err != nil || out != ptrs[0] {
+			t.Error(out, err)
+		}
+	})
+	t.Run("RequireMatch", func(t *testing.T) {
+		out, err := Select(t.Context(), colors, name, WithBackend(&mockBackend{output: "red"}), RequireMatch())
+		if !errors.Is(err, ErrSelectionUnknown) || out != (testColor{}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("FreeText", func(t *testing.T) {
+		out, err := Select(t.Context(), colors, name, WithBackend(&mockBackend{output: "red"}))
+		if !errors.Is(err, ErrSelectionUnknown) || out != (testColor{}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Selections", func(t *testing.T) {
+		mb := &mockBackend{output: "white"}
+		out, err := Select(t.Context(), colors, name, WithBackend(mb), Items("none"))
+		if err != nil || out != colors[1] {
+			t.Error(out, err)
+		}
+		if mb.input != "none\nblack\nwhite\nblue" {
+			t.Errorf("%q", mb.input)
+		}
+
+		out, err = Select(t.Context(), colors, name, WithBackend(&mockBackend{output: "none"}), Items("none"))
+		if !errors.Is(err, ErrSelectionUnknown) || out != (testColor{}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Transform", func(t *testing.T) {
+		out, err := Select(t.Context(), colors, name,
+			WithBackend(&mockBackend{output: "BLUE"}),
+			func(o *Options) { o.Transform = strings.ToLower },
+		)
+		if err != nil || out != colors[2] {
+			t.Error(out, err)
+		}
+	})
+	t.Run("DuplicateLabels", func(t *testing.T) {
+		out, err := Select(t.Context(), append(colors, testColor{"blue", "#0000ff"}), name, WithBackend(&mockBackend{output: "blue"}))
+		if !errors.Is(err, ErrConfigurationInvalid) || out != (testColor{}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		out, err := Select(t.Context(), colors, name, WithBackend(&mockBackend{err: ErrSelectionMissing}))
+		if !errors.Is(err, ErrSelectionMissing) || out != (testColor{}) {
+			t.Error(out, err)
+		}
+	})
+}
@@ -2,47 +2,266 @@ package godmenu
 
 import (
 	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
-	"sort"
+	"iter"
+	"slices"
 	"strings"
 )
 
 type set struct {
 	set   map[string]int
 	items []string
-	conf  struct {
+	// values, when set, holds the value returned for each
+	// item, which may differ from the item shown in the menu.
+	values []string
+	// labels, when set, holds the items before duplicates were
+	// disambiguated, for backends which report the position of
+	// the selection.
+	labels []string
+	// positions, when set, holds the position of each item in
+	// the input, which differ when the policy omits selections.
+	positions []int
+	// ranks, when set, holds the score of each item in the
+	// menu's history, which presents higher scores first.
+	ranks      []float64
+	streaming  bool
+	blanks     int
+	duplicates int
+	conf       struct {
 		transform          func(string) string
 		allowMissingResult bool
 		allowDuplicates    bool
+		policy             SelectionPolicy
 	}
 }
 
 func newset(in []string) *set                            { s := &set{}; return s.init(in) }
 func (s *set) Len() int                                  { return len(s.set) }
+func (s *set) withPolicy(p SelectionPolicy) *set         { s.conf.policy = p; return s }
+func (s *set) withStream(should bool) *set               { s.streaming = should; return s }
 func (s *set) withRequireMatch(should bool) *set         { s.conf.allowMissingResult = !should; return s }
 func (s *set) withTransform(fn func(string) string) *set { s.conf.transform = fn; return s }
-func (s *set) withAllowDuplicates(should bool) *set      { s.conf.allowDuplicates = should; return s }
+func (s *set) withAllowDuplicates(should bool) *set {
+	s.conf.allowDuplicates = should
+	if should {
+		s.disambiguate()
+	}
+	return s
+}
 
+// init normalizes the selections according to the policy (which
+// must be set first), omitting blank selections, and duplicates
+// when the policy dedupes them. Selections which span several lines
+// or contain control characters are shown escaped, and return the
+// selection itself. When the set has values, they must correspond
+// to the selections, and are filtered with them.
+//
+// The index of the items is needed to find duplicates even when the
+// output is not required to match, and so is always built; the
+// values and positions are only kept when they differ from the
+// items and their order.
 func (s *set) init(in []string) *set {
+	policy := s.conf.policy
+
+	var keep map[string]int
+	if policy.dedupe() {
+		keep = make(map[string]int, len(in))
+		for idx := range in {
+			k := policy.normalize(in[idx])
+			if _, ok := keep[k]; !ok || policy&DedupeKeepLast != 0 {
+				keep[k] = idx
+			}
+		}
+	}
+
+	values := s.values
 	s.set = make(map[string]int, len(in))
-	s.items = in
+	s.items = make([]string, 0, len(in))
+	s.positions = nil
+	s.values = nil
+	if values != nil {
+		s.values = make([]string, 0, len(in))
+	}
+
 	for idx := range in {
-		k := strings.TrimSpace(s.items[idx])
-		if k == "" {
+		k := policy.normalize(in[idx])
+		shown := escape(k)
+		switch _, seen := s.set[shown]; {
+		case k == "":
+			s.blanks++
 			continue
+		case keep != nil && keep[k] != idx:
+			continue
+		case seen:
+			s.duplicates++
+		default:
+			s.set[shown] = len(s.items)
+		}
+
+		if s.values == nil && shown != k {
+			// the items before the first escaped
+			// selection are their own values.
+			s.values = append(make([]string, 0, len(in)), s.items...)
+		}
+
+		s.addPosition(idx)
+		s.items = append(s.items, shown)
+		switch {
+		case values != nil:
+			s.values = append(s.values, values[idx])
+		case s.values != nil:
+			s.values = append(s.values, k)
 		}
-		s.set[k] = idx
-		s.items[idx] = k
 	}
+
 	return s
 }
 
+// addPosition records the position in the input of the next item.
+// Positions are only kept once they differ from the items' own.
+func (s *set) addPosition(pos int) {
+	if s.positions == nil {
+		if pos == len(s.items) {
+			return
+		}
+		s.positions = make([]int, len(s.items), cap(s.items))
+		for idx := range s.positions {
+			s.positions[idx] = idx
+		}
+	}
+	s.positions = append(s.positions, pos)
+}
+
+// position returns the position in the input of the item at idx.
+func (s *set) position(idx int) int {
+	if s.positions == nil {
+		return idx
+	}
+	return s.positions[idx]
+}
+
+// disambiguate keeps every duplicate selection, showing the second
+// and later copies with a counter (e.g. "a (2)"), and returning the
+// selection itself.
+func (s *set) disambiguate() {
+	if s.duplicates == 0 {
+		return
+	}
+
+	s.labels = slices.Clone(s.items)
+	if s.values == nil {
+		s.values = slices.Clone(s.items)
+	}
+
+	seen := make(map[string]int, len(s.items))
+	for idx, item := range s.labels {
+		if item == "" {
+			continue
+		}
+		seen[item]++
+		if seen[item] == 1 {
+			continue
+		}
+
+		label := fmt.Sprintf("%s (%d)", item, seen[item])
+		for n := seen[item] + 1; s.check(label); n++ {
+			label = fmt.Sprintf("%s (%d)", item, n)
+		}
+		s.items[idx] = label
+		s.set[label] = idx
+	}
+	s.duplicates = 0
+}
+
+// newItemSet builds a set which shows each item's label (and
+// description) and returns its value. Items with the same label but
+// different values are shown with their value, so that they remain
+// distinguishable; identical items remain duplicates.
+func newItemSet(in []Item, policy SelectionPolicy) *set {
+	type variant struct {
+		value string
+		mixed bool
+	}
+
+	labels := make([]string, len(in))
+	values := make([]string, len(in))
+	variants := make(map[string]variant, len(in))
+	for idx := range in {
+		labels[idx] = in[idx].display()
+		values[idx] = in[idx].value()
+		if v, ok := variants[labels[idx]]; !ok {
+			variants[labels[idx]] = variant{value: values[idx]}
+		} else if !v.mixed && v.value != values[idx] {
+			variants[labels[idx]] = variant{mixed: true}
+		}
+	}
+
+	for idx := range in {
+		if variants[labels[idx]].mixed && labels[idx] != "" && values[idx] != labels[idx] {
+			labels[idx] = fmt.Sprintf("%s (%s)", labels[idx], values[idx])
+		}
+	}
+
+	s := &set{values: values}
+	return s.withPolicy(policy).init(labels)
+}
+
+// stream adds a selection, at the position in the input, while the
+// menu runs, reporting how it is shown and, for backends which report
+// the position of the selection, its label. Blank and duplicate
+// selections are omitted, unless duplicates are allowed.
+func (s *set) stream(sel string, pos int) (string, string, bool) {
+	k := s.conf.policy.normalize(sel)
+	if k == "" {
+		return "", "", false
+	}
+
+	shown := escape(k)
+	label := shown
+	if s.check(shown) {
+		if !s.conf.allowDuplicates {
+			return "", "", false
+		}
+		if s.labels == nil {
+			s.labels = slices.Clone(s.items)
+		}
+		for n := 2; s.check(shown); n++ {
+			shown = fmt.Sprintf("%s (%d)", label, n)
+		}
+	}
+
+	if s.values == nil && shown != k {
+		s.values = slices.Clone(s.items)
+	}
+	if s.values != nil {
+		s.values = append(s.values, k)
+	}
+	if s.labels != nil {
+		s.labels = append(s.labels, label)
+	}
+
+	s.addPosition(pos)
+	s.set[shown] = len(s.items)
+	s.items = append(s.items, shown)
+	return shown, label, true
+}
+
 func (s *set) validate() error {
-	if len(s.items) == 0 {
+	if len(s.items) == 0 && !s.streaming {
 		return fmt.Errorf("must define selections: %w", ErrConfigurationInvalid)
 	}
-	if diff := len(s.items) - len(s.set); diff != 0 {
-		return fmt.Errorf("found %d duplicate selections: %w", diff, ErrConfigurationInvalid)
+
+	var errs []error
+	if s.blanks > 0 && s.conf.policy&DropBlanks == 0 {
+		errs = append(errs, fmt.Errorf("found %d blank selections", s.blanks))
+	}
+	if s.duplicates > 0 {
+		errs = append(errs, fmt.Errorf("found %d duplicate selections", s.duplicates))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", err, ErrConfigurationInvalid)
 	}
 	return nil
 }
@@ -55,24 +274,128 @@ func (s *set) check(key string) bool {
 	return ok
 }
 
-func (s *set) rendered(shouldSort bool) []byte {
-	out := s.selections()
-	if shouldSort {
-		sort.Strings(out)
+// order returns the positions of the items in the order that they
+// are presented, or nil when they are presented in their own order.
+// Ranked items are presented first.
+func (s *set) order(shouldSort bool) []int {
+	if !shouldSort && s.ranks == nil {
+		return nil
+	}
+
+	out := make([]int, len(s.items))
+	for idx := range out {
+		out[idx] = idx
+	}
+	byItem := func(a, b int) int {
+		if !shouldSort {
+			return 0
+		}
+		return strings.Compare(s.items[a], s.items[b])
 	}
-	return []byte(strings.Join(out, "\n"))
+	// ties (only possible in invalid sets) keep their order, as
+	// with a stable sort, which is much slower for large sets.
+	slices.SortFunc(out, func(a, b int) int { return cmp.Or(cmp.Compare(s.rank(b), s.rank(a)), byItem(a, b), cmp.Compare(a, b)) })
+	return out
 }
 
-func (s set) selections() []string { return append(make([]string, 0, len(s.set)), s.items...) }
+// withRank ranks the items by the scores of their values. Items
+// without scores follow those with them.
+func (s *set) withRank(scores map[string]float64) *set {
+	if len(scores) == 0 {
+		return s
+	}
+
+	s.ranks = make([]float64, len(s.items))
+	for idx, item := range s.items {
+		if s.values != nil {
+			item = s.values[idx]
+		}
+		s.ranks[idx] = scores[item]
+	}
+	return s
+}
+
+func (s *set) rank(idx int) float64 {
+	if idx >= len(s.ranks) {
+		return 0
+	}
+	return s.ranks[idx]
+}
+
+// present returns the items in the order, as they are shown in the
+// menu, without copying them. Backends which report the position of
+// the selection (raw) are shown duplicates as they are, rather than
+// disambiguated.
+func (s *set) present(order []int, raw bool) iter.Seq[string] {
+	items := s.items
+	if raw && s.labels != nil {
+		items = s.labels
+	}
+
+	return func(yield func(string) bool) {
+		if order == nil {
+			for _, item := range items {
+				if !yield(item) {
+					return
+				}
+			}
+			return
+		}
+		for _, pos := range order {
+			if !yield(items[pos]) {
+				return
+			}
+		}
+	}
+}
+
+// indexed rewrites output which holds the position of each selection
+// in the order as the items themselves, so that duplicates resolve to
+// the chosen copy. Text that the user entered is kept.
+func (s *set) indexed(order []int, resp Response) []byte {
+	var out bytes.Buffer
+	idx := 0
+	for line := range bytes.Lines(resp.Output) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if idx < len(resp.Indexes) && resp.Indexes[idx] >= 0 && resp.Indexes[idx] < len(s.items) {
+			// streamed selections follow the ordered
+			// selections, in their own order.
+			pos := resp.Indexes[idx]
+			if pos < len(order) {
+				pos = order[pos]
+			}
+			out.WriteString(s.items[pos])
+		} else {
+			out.Write(bytes.TrimRight(line, "\n"))
+		}
+		out.WriteByte('\n')
+		idx++
+	}
+	return out.Bytes()
+}
+
+func (s *set) rendered(shouldSort bool) []byte {
+	var buf bytes.Buffer
+	_ = dmenuBackend.Render(&buf, s.present(s.order(shouldSort), false))
+	return buf.Bytes()
+}
 
 func (s set) processOutput(data []byte, err error) (string, error) {
+	out, _, err := s.resolve(data, err)
+	return out, err
+}
+
+// resolve processes the output as processOutput does, and also
+// reports the position of the selection in the set, or -1 when the
+// output is not one of the selections.
+func (s set) resolve(data []byte, err error) (string, int, error) {
 	switch {
-	case err != nil && len(data) != 0:
-		return "", fmt.Errorf("dmenu failed [%s]: %w", string(data), err)
-	case err != nil && len(data) == 0:
-		return "", fmt.Errorf("dmenu error [%w]: %w ", err, ErrSelectionMissing)
+	case err != nil:
+		return "", -1, err
 	case len(data) == 0:
-		return "", ErrSelectionMissing
+		return "", -1, ErrSelectionMissing
 	}
 
 	out := string(bytes.TrimSpace(data))
@@ -82,12 +405,58 @@ func (s set) processOutput(data []byte, err error) (string, error) {
 	}
 
 	if out == "" {
-		return "", ErrSelectionMissing
+		return "", -1, ErrSelectionMissing
 	}
 
-	if !s.conf.allowMissingResult && !s.check(out) {
-		return "", fmt.Errorf("value %q was not provided: %w", out, ErrSelectionUnknown)
+	idx, ok := s.set[out]
+	switch {
+	case !ok && !s.conf.allowMissingResult:
+		return "", -1, fmt.Errorf("value %q was not provided: %w", out, ErrSelectionUnknown)
+	case !ok:
+		return out, -1, nil
+	case s.values != nil:
+		return s.values[idx], s.position(idx), nil
+	default:
+		return out, s.position(idx), nil
+	}
+}
+
+// resolveLines processes output which holds one selection per line,
+// as from a multi-selection menu, preserving the order of the lines,
+// and reports the position of each selection, as resolve does.
+func (s set) resolveLines(data []byte, err error) ([]string, []int, error) {
+	if err != nil {
+		_, _, err = s.resolve(data, err)
+		return nil, nil, err
 	}
 
-	return out, nil
+	var out []string
+	var positions []int
+	for line := range bytes.Lines(data) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		sel, pos, err := s.resolve(line, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, sel)
+		positions = append(positions, pos)
+	}
+
+	if len(out) == 0 {
+		return nil, nil, ErrSelectionMissing
+	}
+
+	return out, positions, nil
+}
+
+// matches reports if the output from processOutput is the value of
+// one of the selections, rather than text that the user entered or
+// that the transform produced.
+func (s *set) matches(value string) bool {
+	if s.values == nil {
+		return s.check(value)
+	}
+	return value != "" && slices.Contains(s.values, value)
 }
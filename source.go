@@ -0,0 +1,188 @@
+package godmenu
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Source produces items for a menu. Sources are loaded, in order,
+// when the menu runs, and their items are presented after the
+// Selections and Items. The result of the menu reports the name of
+// the source of the chosen item.
+type Source interface {
+	// Name identifies the source in errors and results.
+	Name() string
+	// Items returns the source's items.
+	Items(ctx context.Context) ([]Item, error)
+}
+
+// WithSources adds sources of items to the menu.
+func WithSources(sources ...Source) Arg {
+	return func(o *Options) { o.Sources = extend(o.Sources, sources...) }
+}
+
+// FuncSource returns a source which calls fn for its items.
+func FuncSource(name string, fn func(context.Context) ([]Item, error)) Source {
+	return funcSource{name: name, fn: fn}
+}
+
+type funcSource struct {
+	name string
+	fn   func(context.Context) ([]Item, error)
+}
+
+func (s funcSource) Name() string                              { return s.name }
+func (s funcSource) Items(ctx context.Context) ([]Item, error) { return s.fn(ctx) }
+
+// StaticSource returns a source of the selections.
+func StaticSource(name string, selections ...string) Source {
+	return FuncSource(name, func(context.Context) ([]Item, error) { return labeled(selections), nil })
+}
+
+// ItemSource returns a source of the items.
+func ItemSource(name string, items ...Item) Source {
+	return FuncSource(name, func(context.Context) ([]Item, error) { return items, nil })
+}
+
+// FileSource returns a source of the lines of the file, which is
+// named by its path.
+func FileSource(path string) Source {
+	return FuncSource(path, func(context.Context) ([]Item, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return lines(data)
+	})
+}
+
+// CommandSource returns a source of the lines that the program
+// writes to standard output. Programs which fail are errors, which
+// report what they wrote to standard error.
+func CommandSource(name, program string, args ...string) Source {
+	return FuncSource(name, func(ctx context.Context) ([]Item, error) {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, program, args...)
+		cmd.Stderr = &stderr
+
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%w [%s]", err, msg)
+			}
+			return nil, err
+		}
+		return lines(out)
+	})
+}
+
+// MergeSources returns a source of the items of each of the sources,
+// in order. Each item reports the source it came from.
+func MergeSources(name string, sources ...Source) Source {
+	return FuncSource(name, func(ctx context.Context) ([]Item, error) {
+		var items []Item
+		var errs []error
+		for _, src := range sources {
+			srcItems, err := loadSource(ctx, src)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			items = append(items, srcItems...)
+		}
+		return items, errors.Join(errs...)
+	})
+}
+
+// PrefixSource returns a source of the items of src, whose labels
+// begin with the prefix (e.g. "[git] "). Selecting an item returns
+// its value (or its original label) without the prefix.
+func PrefixSource(prefix string, src Source) Source {
+	return FuncSource(src.Name(), func(ctx context.Context) ([]Item, error) {
+		items, err := sourceItems(ctx, src)
+		for idx := range items {
+			items[idx].Value = items[idx].value()
+			items[idx].Label = prefix + items[idx].Label
+		}
+		return items, err
+	})
+}
+
+// TagSource returns a source of the items of src, which report the
+// tag, rather than the sources they came from, as their source.
+func TagSource(tag string, src Source) Source {
+	return FuncSource(tag, func(ctx context.Context) ([]Item, error) {
+		items, err := sourceItems(ctx, src)
+		for idx := range items {
+			items[idx].Source = tag
+		}
+		return items, err
+	})
+}
+
+// FilterSource returns a source of the items of src for which keep
+// returns true.
+func FilterSource(src Source, keep func(Item) bool) Source {
+	return FuncSource(src.Name(), func(ctx context.Context) ([]Item, error) {
+		items, err := sourceItems(ctx, src)
+		out := items[:0]
+		for _, item := range items {
+			if keep(item) {
+				out = append(out, item)
+			}
+		}
+		return out, err
+	})
+}
+
+// loadSource returns the items of the source, as sourceItems,
+// describing errors with the name of the source.
+func loadSource(ctx context.Context, src Source) ([]Item, error) {
+	items, err := sourceItems(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("loading items from %s: %w", src.Name(), err)
+	}
+	return items, nil
+}
+
+// sourceItems returns a copy of the items of the source, recording
+// the source of items which do not already report one.
+func sourceItems(ctx context.Context, src Source) ([]Item, error) {
+	items, err := src.Items(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Item, len(items))
+	for idx, item := range items {
+		if item.Source == "" {
+			item.Source = src.Name()
+		}
+		out[idx] = item
+	}
+	return out, nil
+}
+
+func labeled(selections []string) []Item {
+	items := make([]Item, len(selections))
+	for idx, sel := range selections {
+		items[idx] = Item{Label: sel}
+	}
+	return items
+}
+
+func lines(data []byte) ([]Item, error) {
+	var items []Item
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		items = append(items, Item{Label: scanner.Text()})
+	}
+	return items, scanner.Err()
+}
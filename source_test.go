@@ -0,0 +1,157 @@
+package godmenu
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	t.Run("Static", func(t *testing.T) {
+		mock := &mockBackend{output: "two"}
+		res, err := RunResult(t.Context(), WithBackend(mock), Items("zero"), WithSources(StaticSource("numbers", "one", "two")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "zero\none\ntwo" {
+			t.Errorf("%q", mock.input)
+		}
+		if res.Value != "two" || res.Index != 2 || res.Source != "numbers" {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("NotFromSource", func(t *testing.T) {
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{output: "zero"}), Items("zero"), WithSources(StaticSource("numbers", "one")))
+		if err != nil || res.Source != "" || res.Index != 0 {
+			t.Error(res, err)
+		}
+	})
+	t.Run("Items", func(t *testing.T) {
+		mock := &mockBackend{output: "Firefox"}
+		res, err := RunResult(t.Context(), WithBackend(mock), WithSources(ItemSource("apps", MakeItem("Firefox", "firefox"))), WithItems(MakeItem("Emacs", "emacs")))
+		if err != nil || res.Value != "firefox" || res.Index != 1 || res.Source != "apps" {
+			t.Error(res, err)
+		}
+	})
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "hosts")
+		if err := os.WriteFile(path, []byte("alpha\nbeta\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{output: "beta"}), WithSources(FileSource(path)))
+		if err != nil || res.Value != "beta" || res.Source != path {
+			t.Error(res, err)
+		}
+
+		_, err = Run(t.Context(), WithBackend(&mockBackend{}), WithSources(FileSource(filepath.Join(t.TempDir(), "missing"))))
+		if !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), "loading items from") {
+			t.Error(err)
+		}
+	})
+	t.Run("Command", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("requires a posix shell")
+		}
+
+		mock := &mockBackend{output: "b"}
+		res, err := RunResult(t.Context(), WithBackend(mock), WithSources(CommandSource("letters", "sh", "-c", "printf 'a\\nb\\n'")))
+		if err != nil || res.Value != "b" || res.Source != "letters" {
+			t.Error(res, err)
+		}
+		if mock.input != "a\nb" {
+			t.Errorf("%q", mock.input)
+		}
+
+		_, err = Run(t.Context(), WithBackend(&mockBackend{}), WithSources(CommandSource("broken", "sh", "-c", "echo no repository >&2; exit 3")))
+		if err == nil || !strings.Contains(err.Error(), "no repository") || !strings.Contains(err.Error(), "broken") {
+			t.Error(err)
+		}
+	})
+	t.Run("Func", func(t *testing.T) {
+		calls := 0
+		src := FuncSource("lazy", func(ctx context.Context) ([]Item, error) {
+			calls++
+			return []Item{{Label: "computed"}}, ctx.Err()
+		})
+
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{output: "computed"}), WithSources(src))
+		if err != nil || res.Source != "lazy" || calls != 1 {
+			t.Error(res, err, calls)
+		}
+	})
+	t.Run("Merge", func(t *testing.T) {
+		src := MergeSources("all",
+			PrefixSource("[git] ", StaticSource("git", "main", "dev")),
+			PrefixSource("[dir] ", StaticSource("dirs", "src")),
+		)
+
+		mock := &mockBackend{output: "[git] dev"}
+		res, err := RunResult(t.Context(), WithBackend(mock), WithSources(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mock.input != "[git] main\n[git] dev\n[dir] src" {
+			t.Errorf("%q", mock.input)
+		}
+		if res.Value != "dev" || res.Source != "git" || res.Index != 1 {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("MergeErrors", func(t *testing.T) {
+		failing := FuncSource("failing", func(context.Context) ([]Item, error) { return nil, errors.New("offline") })
+		_, err := Run(t.Context(), WithBackend(&mockBackend{}), WithSources(MergeSources("all", StaticSource("ok", "a"), failing)))
+		if err == nil || !strings.Contains(err.Error(), "failing: offline") {
+			t.Error(err)
+		}
+	})
+	t.Run("Tag", func(t *testing.T) {
+		src := TagSource("remote", MergeSources("all", StaticSource("a", "one"), StaticSource("b", "two")))
+		res, err := RunResult(t.Context(), WithBackend(&mockBackend{output: "two"}), WithSources(src))
+		if err != nil || res.Source != "remote" {
+			t.Error(res, err)
+		}
+	})
+	t.Run("Filter", func(t *testing.T) {
+		src := FilterSource(StaticSource("files", "a.go", "a_test.go", "b.go"), func(it Item) bool { return !strings.HasSuffix(it.Label, "_test.go") })
+
+		mock := &mockBackend{output: "b.go"}
+		res, err := RunResult(t.Context(), WithBackend(mock), WithSources(src))
+		if err != nil || res.Source != "files" || res.Index != 1 {
+			t.Error(res, err)
+		}
+		if mock.input != "a.go\nb.go" {
+			t.Errorf("%q", mock.input)
+		}
+	})
+	t.Run("Multi", func(t *testing.T) {
+		out, err := RunMulti(t.Context(), WithBackend(&mockBackend{output: "one\nthree\n"}), Items("one"), WithSources(StaticSource("more", "two", "three")))
+		if err != nil || !slices.Equal(out, []string{"one", "three"}) {
+			t.Error(out, err)
+		}
+	})
+	t.Run("CallerItems", func(t *testing.T) {
+		items := make([]Item, 1, 4)
+		items[0] = Item{Label: "mine"}
+		opts := Options{Items: items, Sources: []Source{StaticSource("more", "two")}, Backend: &mockBackend{output: "two"}}
+
+		if _, err := Do(t.Context(), opts); err != nil {
+			t.Fatal(err)
+		}
+		if len(opts.Items) != 1 || len(opts.Sources) != 1 || items[:2][1].Label != "" {
+			t.Errorf("%+v", opts)
+		}
+	})
+	t.Run("Select", func(t *testing.T) {
+		_, err := Select(t.Context(), []int{1, 2}, func(n int) string { return strings.Repeat("x", n) },
+			WithBackend(&mockBackend{output: "extra"}), WithSources(StaticSource("more", "extra")))
+		if !errors.Is(err, ErrSelectionUnknown) {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package godmenu
+
+import (
+	"bufio"
+	"io"
+	"iter"
+)
+
+// FromSeq streams selections from the sequence, after the
+// Selections and Items, while the menu runs. See Options.Stream.
+func FromSeq(seq iter.Seq[string]) Arg {
+	return WithStream(func(yield func(string, error) bool) {
+		for sel := range seq {
+			if !yield(sel, nil) {
+				return
+			}
+		}
+	})
+}
+
+// FromChannel streams selections from the channel until it is
+// closed. The menu stops receiving when it closes, so producers
+// should stop sending when the menu's context is done.
+func FromChannel(ch <-chan string) Arg {
+	return WithStream(func(yield func(string, error) bool) {
+		for sel := range ch {
+			if !yield(sel, nil) {
+				return
+			}
+		}
+	})
+}
+
+// FromReader streams selections from the lines of the reader (for
+// example, the output of find), until the end of the input. Errors
+// reading r are returned by the menu.
+func FromReader(r io.Reader) Arg {
+	return WithStream(func(yield func(string, error) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			if !yield(scanner.Text(), nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield("", err)
+		}
+	})
+}
+
+// WithStream adds a stream of selections, which follows any streams
+// that are already configured.
+func WithStream(stream iter.Seq2[string, error]) Arg {
+	return func(o *Options) {
+		prev := o.Stream
+		if prev == nil {
+			o.Stream = stream
+			return
+		}
+		o.Stream = func(yield func(string, error) bool) {
+			for sel, err := range prev {
+				if !yield(sel, err) {
+					return
+				}
+			}
+			for sel, err := range stream {
+				if !yield(sel, err) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package godmenu
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	t.Run("Seq", func(t *testing.T) {
+		runner := &mockRunner{stdout: "c\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), Items("a"), FromSeq(slices.Values([]string{"b", "a", " ", "c"})), RequireMatch())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "a\nb\nc" {
+			t.Errorf("%q", runner.stdin)
+		}
+		if res.Value != "c" || res.Index != 4 || !res.Matched {
+			t.Errorf("%+v", res)
+		}
+	})
+	t.Run("Channel", func(t *testing.T) {
+		ch := make(chan string)
+		go func() {
+			defer close(ch)
+			for _, sel := range []string{"one", "two", "three"} {
+				ch <- sel
+			}
+		}()
+
+		runner := &mockRunner{stdout: "four\n"}
+		_, err := Run(t.Context(), WithRunner(runner), FromChannel(ch), RequireMatch())
+		if !errors.Is(err, ErrSelectionUnknown) {
+			t.Error(err)
+		}
+		if runner.stdin != "one\ntwo\nthree" {
+			t.Errorf("%q", runner.stdin)
+		}
+	})
+	t.Run("Reader", func(t *testing.T) {
+		runner := &mockRunner{stdout: "./b.go\n"}
+		out, err := Run(t.Context(), WithRunner(runner),
+			FromReader(strings.NewReader("./a.go\n./b.go\n")),
+			FromReader(strings.NewReader("./c.go")))
+		if err != nil || out != "./b.go" {
+			t.Error(out, err)
+		}
+		if runner.stdin != "./a.go\n./b.go\n./c.go" {
+			t.Errorf("%q", runner.stdin)
+		}
+	})
+	t.Run("ReaderError", func(t *testing.T) {
+		broken := iotest.ErrReader(errors.New("disk on fire"))
+		_, err := Run(t.Context(), WithRunner(&mockRunner{stdout: "a"}), Items("a"), FromReader(broken))
+		if err == nil || !strings.Contains(err.Error(), "disk on fire") {
+			t.Error(err)
+		}
+	})
+	t.Run("Duplicates", func(t *testing.T) {
+		runner := &mockRunner{stdout: "a (2)\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), Items("a"), FromSeq(slices.Values([]string{"a", "b"})), AllowDuplicateSelections())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "a\na (2)\nb" || res.Value != "a" || res.Index != 1 {
+			t.Errorf("%q %+v", runner.stdin, res)
+		}
+	})
+	t.Run("Indexed", func(t *testing.T) {
+		runner := &mockRunner{stdout: "2 a\n"}
+		res, err := RunResult(t.Context(), WithRunner(runner), WithBackend(Rofi()), Items("a", "b"), FromSeq(slices.Values([]string{"a"})), AllowDuplicateSelections())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if runner.stdin != "a\nb\na" || res.Value != "a" || res.Index != 2 {
+			t.Errorf("%q %+v", runner.stdin, res)
+		}
+	})
+	t.Run("Escaped", func(t *testing.T) {
+		runner := &mockRunner{stdout: `two\nlines`}
+		out, err := Run(t.Context(), WithRunner(runner), Items("one"), FromSeq(slices.Values([]string{"two\nlines"})))
+		if err != nil || out != "two\nlines" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		out, err := Run(t.Context(), WithRunner(&mockRunner{stdout: "typed"}), FromSeq(slices.Values([]string{})))
+		if err != nil || out != "typed" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for name, arg := range map[string]Arg{
+			"Sorted":   Sorted(),
+			"KeepLast": SetSelectionPolicy(DedupeKeepLast),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := Run(t.Context(), WithRunner(&mockRunner{}), Items("a"), FromSeq(slices.Values([]string{"b"})), arg)
+				if !errors.Is(err, ErrConfigurationInvalid) {
+					t.Error(err)
+				}
+			})
+		}
+	})
+	t.Run("Unending", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("requires a posix shell")
+		}
+
+		// the launcher selects the first selection without
+		// waiting for the end of its input.
+		path := filepath.Join(t.TempDir(), "launcher")
+		if err := os.WriteFile(path, []byte("#!/bin/sh\nread line\necho \"$line\"\n"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		ch := make(chan string)
+		go func() {
+			for {
+				select {
+				case ch <- "again":
+				case <-t.Context().Done():
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		start := time.Now()
+		out, err := Run(t.Context(), DMenuPath(path), Items("first"), FromChannel(ch), AllowDuplicateSelections())
+		if err != nil || out != "first" {
+			t.Error(out, err)
+		}
+		if dur := time.Since(start); dur > 5*time.Second {
+			t.Error("menu waited for the stream", dur)
+		}
+	})
+}
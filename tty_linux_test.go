@@ -0,0 +1,97 @@
+package godmenu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// openPty returns the controlling side of a new pseudo-terminal, and
+// the path of the terminal that the picker opens.
+func openPty(t *testing.T) (*os.File, string) {
+	t.Helper()
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("requires pseudo-terminals:", err)
+	}
+	t.Cleanup(func() { _ = ptmx.Close() })
+
+	conn, err := ptmx.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unlock int32
+	if err := ioctl(conn, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		t.Fatal(err)
+	}
+	var n uint32
+	if err := ioctl(conn, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the picker's drawing is discarded.
+	go func() { _, _ = io.Copy(io.Discard, ptmx) }()
+
+	return ptmx, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestPickerTerminal(t *testing.T) {
+	// run runs the picker on the terminal, failing rather than
+	// blocking when it does not return.
+	run := func(t *testing.T, ctx context.Context, device string) (string, error) {
+		t.Helper()
+		type result struct {
+			out string
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			out, err := Run(ctx, WithBackend(pickerBackend{device: device}), Items("alpha", "bravo"))
+			done <- result{out, err}
+		}()
+
+		select {
+		case res := <-done:
+			return res.out, res.err
+		case <-time.After(5 * time.Second):
+			t.Fatal("the picker did not return")
+			return "", nil
+		}
+	}
+
+	t.Run("Select", func(t *testing.T) {
+		ptmx, device := openPty(t)
+		time.AfterFunc(100*time.Millisecond, func() { _, _ = ptmx.Write([]byte("br\r")) })
+
+		out, err := run(t, t.Context(), device)
+		if err != nil || out != "bravo" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		_, device := openPty(t)
+		ctx, cancel := context.WithTimeout(t.Context(), 300*time.Millisecond)
+		t.Cleanup(cancel)
+
+		out, err := run(t, ctx, device)
+		if !errors.Is(err, ErrTimeout) || out != "" {
+			t.Error(out, err)
+		}
+	})
+	t.Run("ContextCanceled", func(t *testing.T) {
+		_, device := openPty(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		out, err := run(t, ctx, device)
+		if !errors.Is(err, context.Canceled) || out != "" {
+			t.Error(out, err)
+		}
+	})
+}
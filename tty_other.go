@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package godmenu
+
+import (
+	"errors"
+	"syscall"
+)
+
+func makeRaw(syscall.RawConn) (func() error, error)  { return nil, errors.ErrUnsupported }
+func terminalSize(syscall.RawConn) (int, int, error) { return 0, 0, errors.ErrUnsupported }
@@ -0,0 +1,58 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package godmenu
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw puts the terminal into raw mode, as cfmakeraw(3) does,
+// returning a function that restores its previous state.
+func makeRaw(conn syscall.RawConn) (func() error, error) {
+	var state syscall.Termios
+	if err := ioctl(conn, ioctlReadTermios, unsafe.Pointer(&state)); err != nil {
+		return nil, err
+	}
+
+	raw := state
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := ioctl(conn, ioctlWriteTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+
+	return func() error { return ioctl(conn, ioctlWriteTermios, unsafe.Pointer(&state)) }, nil
+}
+
+// terminalSize returns the number of columns and rows of the
+// terminal.
+func terminalSize(conn syscall.RawConn) (int, int, error) {
+	var size struct{ rows, cols, xpixel, ypixel uint16 }
+	if err := ioctl(conn, syscall.TIOCGWINSZ, unsafe.Pointer(&size)); err != nil {
+		return 0, 0, err
+	}
+	return int(size.cols), int(size.rows), nil
+}
+
+// ioctl runs the request on the connection's descriptor. Unlike
+// os.File.Fd, which puts the file into blocking mode, this leaves the
+// file in the poller, so that its read deadlines still apply.
+func ioctl(conn syscall.RawConn, req uintptr, arg unsafe.Pointer) error {
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
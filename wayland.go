@@ -0,0 +1,239 @@
+package godmenu
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Bemenu returns a backend which runs bemenu. Monitors are passed by
+// index; bemenu has no equivalent to dmenu's window embedding.
+func Bemenu() Backend { return bemenuBackend }
+
+// Wmenu returns a backend which runs wmenu. wmenu selects outputs by
+// name rather than index, so the Monitor flag is not passed, and
+// colors must be hexadecimal.
+func Wmenu() Backend { return wmenuBackend }
+
+// Fuzzel returns a backend which runs fuzzel in dmenu mode. Colors
+// must be hexadecimal, and are converted to fuzzel's RRGGBBAA format.
+func Fuzzel() Backend { return fuzzelBackend }
+
+// Tofi returns a backend which runs tofi. Colors must be
+// hexadecimal, and are converted to tofi's #RRGGBBAA format.
+func Tofi() Backend { return tofiBackend }
+
+// Wofi returns a backend which runs wofi in dmenu mode. wofi reads
+// colors and fonts from its stylesheet, so those flags are not
+// passed.
+func Wofi() Backend { return wofiBackend }
+
+var (
+	bemenuBackend = &launcher{name: "bemenu", program: DefaultBemenuPath, args: bemenuArgs}
+	wmenuBackend  = &launcher{name: "wmenu", program: DefaultWmenuPath, args: wmenuArgs, validate: validateHexColors}
+	fuzzelBackend = &launcher{name: "fuzzel", program: DefaultFuzzelPath, args: fuzzelArgs, validate: validateHexColors}
+	tofiBackend   = &launcher{name: "tofi", program: DefaultTofiPath, args: tofiArgs, validate: validateHexColors}
+	wofiBackend   = &launcher{name: "wofi", program: DefaultWofiPath, args: wofiArgs}
+)
+
+func bemenuArgs(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 24)
+
+	if !conf.CaseSensitive {
+		args = append(args, "-i")
+	}
+
+	if conf.Bottom {
+		args = append(args, "-b")
+	}
+
+	if conf.Lines > 0 {
+		args = append(args, "-l", fmt.Sprint(conf.Lines))
+	}
+
+	if conf.Font != "" {
+		args = append(args, "--fn", pangoFont(conf.Font))
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "-p", conf.Prompt)
+	}
+
+	// bemenu colors the title (prompt), filter (input) and
+	// highlighted item separately; the "selected" colors are for
+	// items marked in multi-selection.
+	if conf.BackgroundColor != "" {
+		args = append(args, "--nb", conf.BackgroundColor, "--fb", conf.BackgroundColor)
+	}
+
+	if conf.TextColor != "" {
+		args = append(args, "--nf", conf.TextColor, "--ff", conf.TextColor)
+	}
+
+	if conf.SelectedBgColor != "" {
+		args = append(args, "--hb", conf.SelectedBgColor, "--tb", conf.SelectedBgColor)
+	}
+
+	if conf.SelectedTextColor != "" {
+		args = append(args, "--hf", conf.SelectedTextColor, "--tf", conf.SelectedTextColor)
+	}
+
+	if conf.Monitor != nil {
+		args = append(args, "-m", fmt.Sprint(*conf.Monitor))
+	}
+
+	return args
+}
+
+func wmenuArgs(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 20)
+
+	if !conf.CaseSensitive {
+		args = append(args, "-i")
+	}
+
+	if conf.Bottom {
+		args = append(args, "-b")
+	}
+
+	if conf.Lines > 0 {
+		args = append(args, "-l", fmt.Sprint(conf.Lines))
+	}
+
+	if conf.Font != "" {
+		args = append(args, "-f", pangoFont(conf.Font))
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "-p", conf.Prompt)
+	}
+
+	// like dmenu, the prompt uses the selection colors.
+	args = appendColor(args, "-N", conf.BackgroundColor, "#%s", false)
+	args = appendColor(args, "-n", conf.TextColor, "#%s", false)
+	args = appendColor(args, "-S", conf.SelectedBgColor, "#%s", false)
+	args = appendColor(args, "-s", conf.SelectedTextColor, "#%s", false)
+	args = appendColor(args, "-M", conf.SelectedBgColor, "#%s", false)
+	args = appendColor(args, "-m", conf.SelectedTextColor, "#%s", false)
+
+	return args
+}
+
+func fuzzelArgs(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 12)
+	args = append(args, "--dmenu")
+
+	if conf.Bottom {
+		args = append(args, "--anchor=bottom")
+	}
+
+	if conf.Lines > 0 {
+		args = append(args, fmt.Sprintf("--lines=%d", conf.Lines))
+	}
+
+	if conf.Font != "" {
+		args = append(args, "--font="+fontconfigFont(conf.Font))
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "--prompt="+conf.Prompt)
+	}
+
+	args = appendColor(args, "", conf.BackgroundColor, "--background=%s", true)
+	args = appendColor(args, "", conf.TextColor, "--text-color=%s", true)
+	args = appendColor(args, "", conf.SelectedBgColor, "--selection-color=%s", true)
+	args = appendColor(args, "", conf.SelectedTextColor, "--selection-text-color=%s", true)
+
+	return args
+}
+
+func tofiArgs(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 12)
+
+	if conf.Bottom {
+		args = append(args, "--anchor=bottom")
+	}
+
+	if conf.Lines > 0 {
+		args = append(args, fmt.Sprintf("--num-results=%d", conf.Lines))
+	}
+
+	if conf.Font != "" {
+		name, size := splitFont(conf.Font)
+		args = append(args, "--font="+name)
+		if size != "" {
+			args = append(args, "--font-size="+size)
+		}
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "--prompt-text="+conf.Prompt)
+	}
+
+	args = appendColor(args, "", conf.BackgroundColor, "--background-color=#%s", true)
+	args = appendColor(args, "", conf.TextColor, "--text-color=#%s", true)
+	args = appendColor(args, "", conf.SelectedBgColor, "--selection-background=#%s", true)
+	args = appendColor(args, "", conf.SelectedTextColor, "--selection-color=#%s", true)
+
+	return args
+}
+
+func wofiArgs(opts *Options) []string {
+	conf := opts.Flags
+	args := make([]string, 0, 8)
+	args = append(args, "--dmenu")
+
+	if !conf.CaseSensitive {
+		args = append(args, "--insensitive")
+	}
+
+	if conf.Bottom {
+		args = append(args, "--location=bottom")
+	}
+
+	if conf.Lines > 0 {
+		args = append(args, fmt.Sprintf("--lines=%d", conf.Lines))
+	}
+
+	if conf.Prompt != "" {
+		args = append(args, "--prompt="+conf.Prompt)
+	}
+
+	return args
+}
+
+// appendColor adds a hexadecimal color to the arguments, formatted
+// with format, and preceded by flag, when flag is not empty. Colors
+// which cannot be converted are skipped; validation reports them.
+func appendColor(args []string, flag, color, format string, alpha bool) []string {
+	if color == "" {
+		return args
+	}
+
+	hex, err := hexColor(color, alpha)
+	if err != nil {
+		return args
+	}
+
+	if flag != "" {
+		args = append(args, flag)
+	}
+
+	return append(args, fmt.Sprintf(format, hex))
+}
+
+func validateHexColors(conf *Flags) error {
+	var errs []error
+	for _, color := range []string{conf.BackgroundColor, conf.TextColor, conf.SelectedBgColor, conf.SelectedTextColor} {
+		if color == "" {
+			continue
+		}
+		if _, err := hexColor(color, false); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,145 @@
+package godmenu
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWaylandLaunchers(t *testing.T) {
+	flags := []Arg{
+		Items("one", "two"),
+		MenuPrompt("pick"),
+		MenuLines(5),
+		MenuBottom(),
+		BackgroundColor("#123"),
+		SelectedBgColor("#a0b0c0"),
+	}
+
+	for _, tt := range []struct {
+		name     string
+		backend  Backend
+		expected []string
+	}{
+		{
+			name:    "Bemenu",
+			backend: Bemenu(),
+			expected: []string{
+				"-i", "-b", "-l", "5",
+				"--fn", "Source Code Pro 13",
+				"-p", "pick",
+				"--nb", "#123", "--fb", "#123",
+				"--nf", "#ffffff", "--ff", "#ffffff",
+				"--hb", "#a0b0c0", "--tb", "#a0b0c0",
+				"--hf", "#ffffff", "--tf", "#ffffff",
+			},
+		},
+		{
+			name:    "Wmenu",
+			backend: Wmenu(),
+			expected: []string{
+				"-i", "-b", "-l", "5",
+				"-f", "Source Code Pro 13",
+				"-p", "pick",
+				"-N", "#112233",
+				"-n", "#ffffff",
+				"-S", "#a0b0c0",
+				"-s", "#ffffff",
+				"-M", "#a0b0c0",
+				"-m", "#ffffff",
+			},
+		},
+		{
+			name:    "Fuzzel",
+			backend: Fuzzel(),
+			expected: []string{
+				"--dmenu",
+				"--anchor=bottom",
+				"--lines=5",
+				"--font=Source Code Pro:size=13",
+				"--prompt=pick",
+				"--background=112233ff",
+				"--text-color=ffffffff",
+				"--selection-color=a0b0c0ff",
+				"--selection-text-color=ffffffff",
+			},
+		},
+		{
+			name:    "Tofi",
+			backend: Tofi(),
+			expected: []string{
+				"--anchor=bottom",
+				"--num-results=5",
+				"--font=Source Code Pro",
+				"--font-size=13",
+				"--prompt-text=pick",
+				"--background-color=#112233ff",
+				"--text-color=#ffffffff",
+				"--selection-background=#a0b0c0ff",
+				"--selection-color=#ffffffff",
+			},
+		},
+		{
+			name:    "Wofi",
+			backend: Wofi(),
+			expected: []string{
+				"--dmenu",
+				"--insensitive",
+				"--location=bottom",
+				"--lines=5",
+				"--prompt=pick",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path, record := standIn(t, "one\n", 0)
+
+			out, err := Run(t.Context(), append([]Arg{WithBackend(tt.backend), DMenuPath(path)}, flags...)...)
+			if err != nil || out != "one" {
+				t.Fatal(out, err)
+			}
+
+			args, stdin := record()
+			if !slices.Equal(args, tt.expected) {
+				t.Errorf("got %q\nexpected %q", args, tt.expected)
+			}
+			if stdin != "one\ntwo" {
+				t.Errorf("%q", stdin)
+			}
+			if tt.backend.Name() == "" {
+				t.Error("backends must be named")
+			}
+		})
+	}
+	t.Run("NamedColors", func(t *testing.T) {
+		for _, backend := range []Backend{Fuzzel(), Tofi(), Wmenu()} {
+			path, _ := standIn(t, "one\n", 0)
+			out, err := Run(t.Context(), WithBackend(backend), DMenuPath(path), Items("one"), BackgroundColor("black"))
+			if !errors.Is(err, ErrConfigurationInvalid) || out != "" {
+				t.Error(backend.Name(), out, err)
+			}
+		}
+		for _, backend := range []Backend{Bemenu(), Wofi()} {
+			path, _ := standIn(t, "one\n", 0)
+			out, err := Run(t.Context(), WithBackend(backend), DMenuPath(path), Items("one"), BackgroundColor("black"))
+			if err != nil || out != "one" {
+				t.Error(backend.Name(), out, err)
+			}
+		}
+	})
+	t.Run("HexColor", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"#abc":    "aabbcc",
+			"#A0B0C0": "a0b0c0",
+		} {
+			if out, err := hexColor(input, false); err != nil || out != expected {
+				t.Error(input, out, err)
+			}
+		}
+		for _, input := range []string{"black", "#abcd", "#ggg", ""} {
+			if out, err := hexColor(input, true); err == nil {
+				t.Error(input, out)
+			}
+		}
+	})
+}